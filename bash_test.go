@@ -0,0 +1,279 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// bashNames are what the generated trees are made of.
+var bashNames = []string{"a", "b", "ab", "ba", ".a", "a.b", "c1"}
+
+// bashSegments are the path elements of the generated patterns, in the
+// subset of the syntax bash shares with fileglob.
+var bashSegments = []string{
+	"a", "ab", ".a", "*", "?", "a*", "*b", "*.*", "??", "[ab]", "[!a]", "[a-b]*",
+	`\a`, `\*`, "{a,b}", "{a,.a}", "{,a}b", "{a*,?b}", "*{1,b}", ".*", "[.a]*",
+}
+
+// bashSuperSegments are path elements with "**", for patterns compared with
+// StrictDoublestar.
+var bashSuperSegments = []string{"**", "a**", "**b"}
+
+// bashDifference is a path matched by only one of Glob and bash.
+type bashDifference struct {
+	pattern string
+	path    string
+	// onlyGlob is true if the path is matched by Glob only, and false if it
+	// is matched by bash only.
+	onlyGlob bool
+	isDir    bool
+	// dotglob are the paths bash matches with the dotglob option.
+	dotglob []string
+}
+
+// bashDivergence is an intentional difference between Glob and bash.
+type bashDivergence struct {
+	name     string
+	explains func(d bashDifference) bool
+}
+
+var bashDivergences = []bashDivergence{
+	{
+		// bash only matches a leading dot in a path element literally,
+		// unless dotglob is set, while fileglob matches it like any
+		// other character
+		name: "hidden files",
+		explains: func(d bashDifference) bool {
+			return d.onlyGlob && slices.Contains(d.dotglob, d.path)
+		},
+	},
+	{
+		// a pattern that matches "." matches the root directory the walk
+		// starts from in fileglob, while bash never matches "."
+		name: "root directory",
+		explains: func(d bashDifference) bool {
+			return d.onlyGlob && d.path == "."
+		},
+	},
+	{
+		// with StrictDoublestar, "a/**" matches "a" like in doublestar,
+		// even if it is a file, while bash only matches directories
+		name: "trailing super star",
+		explains: func(d bashDifference) bool {
+			return d.onlyGlob && !d.isDir && strings.HasSuffix(d.pattern, "/**")
+		},
+	},
+}
+
+// bashGlob returns what bash matches for each of the patterns in dir, with
+// the given extra options.
+func bashGlob(t *testing.T, dir string, patterns []string, opts ...string) [][]string {
+	t.Helper()
+
+	// patterns are written unquoted, for bash to expand them, and each
+	// result ends with a \1 element
+	var script strings.Builder
+	for _, pattern := range patterns {
+		script.WriteString("printf '%s\\0' " + pattern + "; printf '\\1\\0'\n")
+	}
+	args := []string{"--norc", "--noprofile", "-O", "globstar", "-O", "nullglob"}
+	for _, opt := range opts {
+		args = append(args, "-O", opt)
+	}
+	cmd := exec.Command("bash", append(args, "-c", script.String())...)
+	cmd.Dir = dir
+	cmd.Env = []string{"LC_ALL=C", "PATH=" + os.Getenv("PATH")}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash: %v", err)
+	}
+
+	results := make([][]string, 0, len(patterns))
+	var current []string
+	for word := range strings.SplitSeq(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		if word == "\x01" {
+			results = append(results, normalizeBash(dir, current))
+			current = nil
+			continue
+		}
+		current = append(current, word)
+	}
+	if len(results) != len(patterns) {
+		t.Fatalf("bash returned %d results for %d patterns", len(results), len(patterns))
+	}
+	return results
+}
+
+// normalizeBash makes bash results comparable with Glob ones: braces are
+// expanded into words which are printed even if they do not exist, each word
+// is sorted on its own, and directories matched by a trailing "**" end with
+// a slash.
+func normalizeBash(dir string, words []string) []string {
+	var paths []string
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		p := path.Clean(word)
+		if _, err := os.Lstat(filepath.Join(dir, p)); err != nil {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// randomBashTree creates files and directories with bashNames in dir, up to
+// three levels deep.
+func randomBashTree(t *testing.T, r *rand.Rand, dir string, level int) {
+	t.Helper()
+	for _, name := range bashNames {
+		p := filepath.Join(dir, name)
+		switch n := r.IntN(4); {
+		case n == 0:
+			continue
+		case n == 1 || level == 3:
+			if err := os.WriteFile(p, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			if err := os.Mkdir(p, 0o700); err != nil {
+				t.Fatal(err)
+			}
+			randomBashTree(t, r, p, level+1)
+		}
+	}
+}
+
+func randomBashPattern(r *rand.Rand, super bool) string {
+	segments := make([]string, 1+r.IntN(3))
+	for i := range segments {
+		segments[i] = bashSegments[r.IntN(len(bashSegments))]
+		if super && r.IntN(3) == 0 {
+			segments[i] = bashSuperSegments[r.IntN(len(bashSuperSegments))]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestBashDifferential(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("runs bash")
+	}
+	if isWindows() {
+		t.Skip("no bash on windows")
+	}
+	if err := exec.Command("bash", "-O", "globstar", "-c", "true").Run(); err != nil {
+		t.Skip("bash with globstar is not available")
+	}
+
+	testCases := []struct {
+		name  string
+		super bool
+		opts  []OptFunc
+	}{
+		{"default", false, []OptFunc{MatchDirectoryAsFile}},
+		{"strict doublestar", true, []OptFunc{MatchDirectoryAsFile, StrictDoublestar}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			r := rand.New(rand.NewPCG(3, 4)) //nolint:gosec
+			explained := map[string]int{}
+			for range 10 {
+				root := t.TempDir()
+				randomBashTree(t, r, root, 1)
+
+				patterns := make([]string, 100)
+				for i := range patterns {
+					patterns[i] = randomBashPattern(r, testCase.super)
+				}
+				expected := bashGlob(t, root, patterns)
+				dotglob := bashGlob(t, root, patterns, "dotglob")
+
+				for i, pattern := range patterns {
+					matches, err := Glob(pattern, append([]OptFunc{WithFs(os.DirFS(root))}, testCase.opts...)...)
+					if errors.Is(err, fs.ErrNotExist) {
+						err = nil // a single missing path is not matched by bash either
+					}
+					if err != nil {
+						t.Errorf("%s: %v", pattern, err)
+						continue
+					}
+					slices.Sort(matches)
+
+					for _, path := range symmetricDifference(matches, expected[i]) {
+						info, err := os.Lstat(filepath.Join(root, path))
+						if err != nil {
+							t.Fatal(err)
+						}
+						diff := bashDifference{
+							pattern:  pattern,
+							path:     path,
+							onlyGlob: slices.Contains(matches, path),
+							isDir:    info.IsDir(),
+							dotglob:  dotglob[i],
+						}
+						j := slices.IndexFunc(bashDivergences, func(d bashDivergence) bool {
+							return d.explains(diff)
+						})
+						if j < 0 {
+							t.Errorf("%s: %q is matched by Glob: %v, by bash: %v", pattern, path, diff.onlyGlob, !diff.onlyGlob)
+							continue
+						}
+						explained[bashDivergences[j].name]++
+					}
+				}
+			}
+			t.Logf("explained differences: %v", explained)
+		})
+	}
+}
+
+// symmetricDifference returns the values of a or b that are not in both,
+// which are sorted.
+func symmetricDifference(a, b []string) []string {
+	var diff []string
+	for _, v := range a {
+		if _, found := slices.BinarySearch(b, v); !found {
+			diff = append(diff, v)
+		}
+	}
+	for _, v := range b {
+		if _, found := slices.BinarySearch(a, v); !found {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func TestBashGlob(t *testing.T) {
+	t.Parallel()
+	if isWindows() {
+		t.Skip("no bash on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+
+	root := t.TempDir()
+	for _, name := range []string{"a", "b", ".c"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	is.New(t).Equal([][]string{{"a", "b"}, {"a"}, nil}, bashGlob(t, root, []string{"*", "{a,x}", "nope*"}))
+}
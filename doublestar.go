@@ -0,0 +1,116 @@
+package fileglob
+
+import (
+	"strings"
+)
+
+// StrictDoublestar makes "**" match like in doublestar, zsh or gitignore,
+// instead of like in gobwas/glob. It only has a special meaning as a whole
+// path element, where it matches zero or more directories, so:
+//
+//   - "a/**/b" matches "a/b", "a/x/b" and "a/x/y/b"
+//   - "**/b" matches "b" and "x/b"
+//   - "a/**" matches "a" and everything inside of it
+//
+// Anywhere else, like in "a**b" or "foo/**.go", it matches like "*".
+func StrictDoublestar(opts *globOptions) {
+	opts.strictDoublestar = true
+}
+
+// strictDoublestar rewrites the "**" of the pattern into what gobwas matches
+// like StrictDoublestar describes, so "a/**/b" becomes "a/{**/,}b".
+func strictDoublestar(pattern string, extended bool) (string, error) {
+	if !strings.Contains(pattern, "**") {
+		return pattern, nil
+	}
+	p, err := parsePattern(pattern, extended)
+	if err != nil {
+		return "", err
+	}
+	return strictSuperStars(p, true, true).String(), nil
+}
+
+// strictSuperStars rewrites the "**" of the pattern. The pattern can be a
+// branch of alternatives, in which case starts and ends tell whether it starts
+// and ends a path element.
+func strictSuperStars(p Pattern, starts, ends bool) Pattern {
+	last := len(p.Segments) - 1
+	segments := make([]Segment, 0, len(p.Segments))
+	for i, segment := range p.Segments {
+		whole := (i > 0 || starts) && (i < last || ends)
+		if whole && isSuperStarSegment(segment) {
+			// "**/**" matches the same as "**"
+			if n := len(segments); n == 0 || !isSuperStarSegment(segments[n-1]) {
+				segments = append(segments, segment)
+			}
+			continue
+		}
+
+		nodes := make([]Node, len(segment.Nodes))
+		for j, n := range segment.Nodes {
+			switch n := n.(type) {
+			case SuperStar:
+				nodes[j] = Star{}
+			case Alternatives:
+				branches := make([]Pattern, len(n.Branches))
+				for k, branch := range n.Branches {
+					branches[k] = strictSuperStars(
+						branch,
+						j == 0 && (i > 0 || starts),
+						j == len(segment.Nodes)-1 && (i < last || ends),
+					)
+				}
+				nodes[j] = Alternatives{Branches: branches}
+			case ExtGlob:
+				branches := make([]Pattern, len(n.Branches))
+				for k, branch := range n.Branches {
+					branches[k] = strictSuperStars(branch, false, false)
+				}
+				nodes[j] = ExtGlob{Op: n.Op, Branches: branches}
+			default:
+				nodes[j] = n
+			}
+		}
+		segments = append(segments, Segment{Nodes: nodes})
+	}
+
+	if len(segments) == 1 {
+		return Pattern{Segments: segments}
+	}
+
+	// the "**" left are whole path elements: followed by another one, it
+	// becomes "{**/,}" at its start, and otherwise "{/**,}" at the end of the
+	// previous one
+	directories := Pattern{Segments: []Segment{{Nodes: []Node{SuperStar{}}}, {}}}
+	nothing := Pattern{Segments: []Segment{{}}}
+	rewritten := make([]Segment, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		if !isSuperStarSegment(segment) {
+			rewritten = append(rewritten, segment)
+			continue
+		}
+		if i < len(segments)-1 {
+			next := segments[i+1]
+			alt := Alternatives{Branches: []Pattern{directories, nothing}}
+			rewritten = append(rewritten, Segment{Nodes: append([]Node{alt}, next.Nodes...)})
+			i++
+			continue
+		}
+		previous := &rewritten[len(rewritten)-1]
+		alt := Alternatives{Branches: []Pattern{
+			{Segments: []Segment{{}, {Nodes: []Node{SuperStar{}}}}},
+			nothing,
+		}}
+		previous.Nodes = append(previous.Nodes, alt)
+	}
+	return Pattern{Segments: rewritten}
+}
+
+func isSuperStarSegment(segment Segment) bool {
+	if len(segment.Nodes) != 1 {
+		return false
+	}
+	_, ok := segment.Nodes[0].(SuperStar)
+	return ok
+}
@@ -0,0 +1,154 @@
+package fileglob
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestStrictDoublestar(t *testing.T) {
+	t.Parallel()
+
+	// conformance with doublestar, which zsh and gitignore agree with
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "ab", false},
+		{"a/**/b", "a/xb", false},
+		{"a/**/b", "ax/b", false},
+		{"**/b", "b", true},
+		{"**/b", "x/b", true},
+		{"**/b", "x/y/b", true},
+		{"**/b", "xb", false},
+		{"a/**", "a", true},
+		{"a/**", "a/x", true},
+		{"a/**", "a/x/y", true},
+		{"a/**", "ab", false},
+		{"a/**", "ab/x", false},
+		{"**", "a", true},
+		{"**", "a/b", true},
+		{"a**b", "ab", true},
+		{"a**b", "axb", true},
+		{"a**b", "a/b", false},
+		{"a**b", "ax/yb", false},
+		{"foo/**.go", "foo/x.go", true},
+		{"foo/**.go", "foo/x/y.go", false},
+		{"**.go", "x.go", true},
+		{"**.go", "x/y.go", false},
+		{"a/**/**/b", "a/b", true},
+		{"a/**/**/b", "a/x/y/b", true},
+		{"**/*.go", "x.go", true},
+		{"**/*.go", "a/b/x.go", true},
+		{"a/**/*.go", "a/x.go", true},
+		{"a/**/*.go", "a/b/x.go", true},
+		{"a/**/*.go", "x.go", false},
+		{"**/a/**", "a", true},
+		{"**/a/**", "x/a/y", true},
+		{"**/a/**", "xa/y", false},
+		{"{a,b}/**/c", "a/c", true},
+		{"{a,b}/**/c", "b/x/c", true},
+		{"{a/**/c,d}", "a/c", true},
+		{"{a/**,d}", "a", true},
+		{"{a/**,d}", "a/b/c", true},
+		{"x{/**/,}y", "x/a/b/y", true},
+		{"x{a**,b}", "xab", true},
+		{"x{a**,b}", "xa/b", false},
+		{"a/**b/c", "a/xb/c", true},
+		{"a/**b/c", "a/x/b/c", false},
+		{`a/\**/b`, "a/*/b", true},
+		{`a/\**/b`, "a/*x/b", true},
+		{`a/\**/b`, "a/x/y/b", false},
+	}
+
+	for _, testCase := range testCases {
+		for _, extended := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s %s extended=%v", testCase.pattern, testCase.path, extended), func(t *testing.T) {
+				t.Parallel()
+				is := is.New(t)
+				pattern, err := strictDoublestar(testCase.pattern, extended)
+				is.NoErr(err)
+				options := &globOptions{extendedGlob: extended, strictDoublestar: true}
+				matcher, err := options.compile(pattern)
+				is.NoErr(err)
+				is.Equal(testCase.match, matcher.Match(testCase.path)) // should match
+			})
+		}
+	}
+}
+
+func TestStrictDoublestarRewrite(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"a/**/b", "a/{**/,}b"},
+		{"**/b", "{**/,}b"},
+		{"a/**", "a{/**,}"},
+		{"**", "**"},
+		{"a/**/**/b", "a/{**/,}b"},
+		{"a**b/**.go", "a*b/*.go"},
+		{"{a,b/**}/c", "{a,b{/**,}}/c"},
+		{"@(a**)/**/b", "@(a*)/{**/,}b"},
+		{"a/*/b", "a/*/b"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			pattern, err := strictDoublestar(testCase.pattern, true)
+			is.NoErr(err)
+			is.Equal(testCase.expected, pattern)
+		})
+	}
+}
+
+func TestGlobStrictDoublestar(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"a/b":      {},
+		"a/x/b":    {},
+		"a/x/y/b":  {},
+		"a/x.go":   {},
+		"a/x/y.go": {},
+		"ab":       {},
+	}
+
+	testCases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"a/**/b", []string{"a/b", "a/x/b", "a/x/y/b"}},
+		{"**/b", []string{"a/b", "a/x/b", "a/x/y/b"}},
+		{"a/**.go", []string{"a/x.go"}},
+		{"a**", []string{"a/b", "a/x/b", "a/x/y/b", "a/x/y.go", "a/x.go", "ab"}},
+		{"a/**", []string{"a/b", "a/x/b", "a/x/y/b", "a/x/y.go", "a/x.go"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			matches, err := Glob(testCase.pattern, WithFs(fsys), StrictDoublestar)
+			is.NoErr(err)
+			is.Equal(testCase.expected, matches)
+
+			for _, path := range []string{"a/b", "a/x/b", "a/x.go", "a/x/y.go", "ab"} {
+				e, err := Explain(testCase.pattern, path, WithFs(fsys), StrictDoublestar)
+				is.NoErr(err)
+				is.Equal(slices.Contains(matches, path), e.Matched) // should agree with Glob
+			}
+		})
+	}
+}
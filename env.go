@@ -0,0 +1,139 @@
+package fileglob
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandEnv expands the environment variables of the pattern, written as
+// `$NAME` or `${NAME}`, like `$BUILD_DIR/**/*.deb`. Their values are matched
+// literally, and a "$" that does not start a variable name, or that is
+// escaped, like `\$NAME`, is left as is. Glob fails if a variable is not set.
+//
+// MaybeRootFS applies to the expanded pattern, whether it is set before or
+// after ExpandEnv.
+func ExpandEnv(opts *globOptions) {
+	ExpandEnvWith(os.LookupEnv)(opts)
+}
+
+// ExpandEnvWith is like ExpandEnv, with the values of the variables returned
+// by lookup instead of the environment.
+func ExpandEnvWith(lookup func(name string) (string, bool)) OptFunc {
+	return func(opts *globOptions) {
+		pattern, err := expandEnv(opts.pattern, lookup)
+		opts.setExpanded(pattern, err)
+	}
+}
+
+// ExpandHome expands a "~" starting the pattern, like in `~/.config/*.yaml`,
+// into the home directory of the current user. Its path is matched literally,
+// and "~user" is left as is.
+//
+// MaybeRootFS applies to the expanded pattern, whether it is set before or
+// after ExpandHome.
+func ExpandHome(opts *globOptions) {
+	ExpandHomeWith(os.UserHomeDir)(opts)
+}
+
+// ExpandHomeWith is like ExpandHome, with the home directory returned by home.
+func ExpandHomeWith(home func() (string, error)) OptFunc {
+	return func(opts *globOptions) {
+		pattern, err := expandHome(opts.pattern, home)
+		opts.setExpanded(pattern, err)
+	}
+}
+
+// setExpanded sets the pattern an option expanded, resolving a "../" it may
+// now start with, and detects the root directory again for MaybeRootFS.
+func (opts *globOptions) setExpanded(pattern string, err error) {
+	if err == nil {
+		pattern, err = resolveParent(pattern)
+	}
+	if err != nil {
+		opts.setErr(err)
+		return
+	}
+	opts.pattern = pattern
+	if opts.maybeRootFS {
+		opts.detectRoot()
+	}
+}
+
+// expandEnv replaces the variables of the pattern with their quoted values.
+func expandEnv(pattern string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(pattern, "$") {
+		return pattern, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			end := min(i+2, len(pattern))
+			sb.WriteString(pattern[i:end])
+			i = end - 1
+		case '$':
+			name, n := envName(pattern[i+1:])
+			if name == "" {
+				sb.WriteByte(c)
+				continue
+			}
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("failed to expand pattern: environment variable %s is not set", name)
+			}
+			sb.WriteString(quoteMeta(filepath.ToSlash(value)))
+			i += n
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// envName returns the name of the variable at the start of s, which follows
+// a "$", and the length it is written with, braces included.
+func envName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isEnvName(s[1:end]) {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	n := 0
+	for n < len(s) && isEnvNameChar(s[n], n == 0) {
+		n++
+	}
+	return s[:n], n
+}
+
+func isEnvName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isEnvNameChar(s[i], i == 0) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isEnvNameChar reports whether c can be part of a variable name, which does
+// not start with a digit.
+func isEnvNameChar(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+// expandHome replaces a "~" starting the pattern with the quoted home
+// directory.
+func expandHome(pattern string, home func() (string, error)) (string, error) {
+	if pattern != "~" && !strings.HasPrefix(pattern, "~"+separatorString) {
+		return pattern, nil
+	}
+	dir, err := home()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand pattern: %w", err)
+	}
+	return quoteMeta(strings.TrimSuffix(filepath.ToSlash(dir), separatorString)) + pattern[1:], nil
+}
@@ -0,0 +1,191 @@
+package fileglob
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func testLookup(name string) (string, bool) {
+	value, ok := map[string]string{
+		"DIR":    "dist",
+		"EMPTY":  "",
+		"META":   "a*[b]{c}",
+		"NESTED": "$DIR",
+	}[name]
+	return value, ok
+}
+
+func testHome() (string, error) { return "/home/me", nil }
+
+func TestExpandEnv(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"$DIR/**/*.deb", "dist/**/*.deb"},
+		{"${DIR}/*.deb", "dist/*.deb"},
+		{"a${DIR}b", "adistb"},
+		{"$DIR$DIR", "distdist"},
+		{"$EMPTY/a", "/a"},
+		{"$META/*", `a\*\[b\]\{c\}/*`},
+		{"$NESTED", "$DIR"},
+		{`\$DIR`, `\$DIR`},
+		{"a$", "a$"},
+		{"$1", "$1"},
+		{"${1}", "${1}"},
+		{"${DIR", "${DIR"},
+		{"${a,b}", "${a,b}"},
+		{"*.go", "*.go"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			options, err := ResolveOptions(testCase.pattern, ExpandEnvWith(testLookup))
+			is.NoErr(err)
+			is.Equal(testCase.expected, options.Pattern)
+		})
+	}
+
+	t.Run("not set", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob("$NOPE/*", ExpandEnvWith(testLookup))
+		is.Equal("failed to expand pattern: environment variable NOPE is not set", err.Error())
+		is.Equal(err, ValidPattern("$NOPE/*", ExpandEnvWith(testLookup)))
+	})
+
+	t.Run("literal values", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("$META/*", ExpandEnvWith(testLookup), WithFs(fstest.MapFS{
+			"a*[b]{c}/x": {},
+			"ab{c}/y":    {},
+		}))
+		is.NoErr(err)
+		is.Equal([]string{"a*[b]{c}/x"}, matches)
+	})
+}
+
+// TestExpandEnvironment is not parallel, as it sets an environment variable.
+func TestExpandEnvironment(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("FILEGLOB_TEST_DIR", "a")
+	matches, err := Glob("$FILEGLOB_TEST_DIR/*", ExpandEnv, WithFs(fstest.MapFS{
+		"a/b": {},
+		"c/d": {},
+	}))
+	is.NoErr(err)
+	is.Equal([]string{"a/b"}, matches)
+}
+
+func TestExpandHome(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"~/.config/app/*.yaml", "/home/me/.config/app/*.yaml"},
+		{"~", "/home/me"},
+		{"~user/a", "~user/a"},
+		{"a/~/b", "a/~/b"},
+		{`\~/a`, `\~/a`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			options, err := ResolveOptions(testCase.pattern, ExpandHomeWith(testHome))
+			is.NoErr(err)
+			is.Equal(testCase.expected, options.Pattern)
+		})
+	}
+
+	t.Run("quoted", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("~/*", ExpandHomeWith(func() (string, error) {
+			return "/home/[me]/", nil
+		}))
+		is.NoErr(err)
+		is.Equal(`/home/\[me\]/*`, options.Pattern)
+		is.Equal("/home/[me]", options.StaticPrefix)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		errNoHome := errors.New("no home")
+		_, err := Glob("~/a", ExpandHomeWith(func() (string, error) {
+			return "", errNoHome
+		}))
+		is.True(errors.Is(err, errNoHome))
+	})
+}
+
+func TestExpandMaybeRootFS(t *testing.T) {
+	t.Parallel()
+	wd, err := os.Getwd()
+	is.New(t).NoErr(err)
+	dir := filepath.Join(wd, ".github")
+	prefix := "/"
+	if isWindows() {
+		prefix = filepath.VolumeName(wd) + "/"
+	}
+	lookup := func(string) (string, bool) { return dir, true }
+	home := func() (string, error) { return dir, nil }
+	parent := func(string) (string, bool) {
+		return "../" + filepath.Base(wd) + "/.github", true
+	}
+
+	testCases := []struct {
+		name    string
+		pattern string
+		opts    []OptFunc
+	}{
+		{"env before", "$DIR/*", []OptFunc{ExpandEnvWith(lookup), MaybeRootFS}},
+		{"env after", "$DIR/*", []OptFunc{MaybeRootFS, ExpandEnvWith(lookup)}},
+		{"home before", "~/*", []OptFunc{ExpandHomeWith(home), MaybeRootFS}},
+		{"home after", "~/*", []OptFunc{MaybeRootFS, ExpandHomeWith(home)}},
+		{"parent before", "$DIR/*", []OptFunc{ExpandEnvWith(parent), MaybeRootFS}},
+		{"parent after", "$DIR/*", []OptFunc{MaybeRootFS, ExpandEnvWith(parent)}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			options, err := ResolveOptions(testCase.pattern, testCase.opts...)
+			is.NoErr(err)
+			is.Equal(prefix, options.Prefix)
+			is.Equal("os.dirFS", options.FS)
+			is.Equal(strings.TrimPrefix(toNixPath(dir), prefix), options.StaticPrefix)
+
+			matches, err := Glob(testCase.pattern, testCase.opts...)
+			is.NoErr(err)
+			is.True(len(matches) > 0) // expected the contents of .github
+			for _, match := range matches {
+				is.True(strings.HasPrefix(match, toNixPath(dir)+"/"))
+			}
+		})
+	}
+
+	t.Run("without MaybeRootFS", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("$DIR/*", ExpandEnvWith(lookup))
+		is.NoErr(err)
+		is.Equal("", options.Prefix)
+	})
+}
@@ -0,0 +1,154 @@
+package fileglob
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// maxExpansions is the maximum number of patterns ExpandBraces returns.
+const maxExpansions = 10000
+
+// maxStaticPrefixes is the maximum number of static prefixes Glob walks for a
+// pattern, as each of them is stat'ed, even if most are missing. Above it, a
+// single walk from the static prefix of the whole pattern is cheaper.
+const maxStaticPrefixes = 64
+
+// errTooManyExpansions is returned by ExpandBraces when a pattern expands to
+// more than maxExpansions patterns.
+var errTooManyExpansions = errors.New("too many brace expansions")
+
+// ExpandBraces expands the alternatives of the given pattern, returning the
+// patterns without braces it is equivalent to, in order and without
+// duplicates. For example, `{a,b}/{x,y{1,2}}.txt` expands to `a/x.txt`,
+// `a/y1.txt`, `a/y2.txt`, `b/x.txt`, `b/y1.txt` and `b/y2.txt`.
+//
+// Bash sequence expressions are expanded as well, so `{1..3}` expands to `1`,
+// `2` and `3`. A pattern without alternatives expands to itself. It is an
+// error for a pattern to expand to more than 10000 patterns.
+func ExpandBraces(pattern string, opts ...OptFunc) ([]string, error) {
+	options := compileOptions(opts, pattern)
+	return expandBraces(options.pattern, options.extendedGlob)
+}
+
+func expandBraces(pattern string, extended bool) ([]string, error) {
+	pattern, err := expandSequences(pattern)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePattern(pattern, extended)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := expandPattern(p)
+	if err != nil {
+		return nil, err
+	}
+	return compactInOrder(expanded), nil
+}
+
+func expandPattern(p Pattern) ([]string, error) {
+	expanded := []string{""}
+	for i, segment := range p.Segments {
+		if i > 0 {
+			expanded = appendToAll(expanded, []string{separatorString})
+		}
+		for _, n := range segment.Nodes {
+			if l, ok := n.(Literal); ok {
+				// what comes before may end with an extended glob operator
+				// once expanded, like "{a,@}(b)", so a leading "(" is escaped
+				var sb strings.Builder
+				l.write(&sb, "", true)
+				expanded = appendToAll(expanded, []string{sb.String()})
+				continue
+			}
+			alt, ok := n.(Alternatives)
+			if !ok {
+				expanded = appendToAll(expanded, []string{n.String()})
+				continue
+			}
+
+			var branches []string
+			for _, branch := range alt.Branches {
+				b, err := expandPattern(branch)
+				if err != nil {
+					return nil, err
+				}
+				branches = append(branches, b...)
+			}
+			if len(expanded)*len(branches) > maxExpansions {
+				return nil, errTooManyExpansions
+			}
+			expanded = appendToAll(expanded, branches)
+		}
+	}
+	return expanded, nil
+}
+
+// appendToAll returns every prefix followed by every suffix.
+func appendToAll(prefixes, suffixes []string) []string {
+	result := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			result = append(result, prefix+suffix)
+		}
+	}
+	return result
+}
+
+// compactInOrder removes duplicates, keeping the first occurrence.
+func compactInOrder(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	return slices.DeleteFunc(values, func(v string) bool {
+		if seen[v] {
+			return true
+		}
+		seen[v] = true
+		return false
+	})
+}
+
+// staticPrefixes returns the static prefixes of each pattern the given one
+// expands to, without the ones inside of others, sorted in the order a walk
+// would find them. Walking all of them covers everything the pattern matches.
+//
+// If the pattern expands to too many patterns, or to too many static
+// prefixes, its only static prefix is the one of the pattern itself.
+func staticPrefixes(pattern string, extended bool) ([]string, error) {
+	prefix, err := staticPrefix(pattern, extended)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := expandBraces(pattern, extended)
+	if errors.Is(err, errTooManyExpansions) || len(expanded) < 2 {
+		return []string{prefix}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]string, 0, len(expanded))
+	for _, p := range expanded {
+		prefix, err := staticPrefix(p, extended)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	if slices.Contains(prefixes, ".") {
+		return []string{"."}, nil
+	}
+	// sorted depth first, the prefixes inside of another one follow it
+	slices.SortFunc(prefixes, compareDepthFirst)
+	walked := prefixes[:0:0]
+	for _, prefix := range prefixes {
+		if len(walked) == 0 || !isInside(prefix, walked[len(walked)-1]) {
+			walked = append(walked, prefix)
+		}
+	}
+	if len(walked) > maxStaticPrefixes {
+		return []string{prefix}, nil
+	}
+	return walked, nil
+}
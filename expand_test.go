@@ -0,0 +1,141 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestExpandBraces(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"a/*.txt", []string{"a/*.txt"}},
+		{"{a,b}/{x,y{1,2}}.txt", []string{"a/x.txt", "a/y1.txt", "a/y2.txt", "b/x.txt", "b/y1.txt", "b/y2.txt"}},
+		{"{src,pkg}/**/*.go", []string{"src/**/*.go", "pkg/**/*.go"}},
+		{"{a/b,c}/d", []string{"a/b/d", "c/d"}},
+		{"a{,.bak}", []string{"a", "a.bak"}},
+		{"{a,a,b}", []string{"a", "b"}},
+		{`{a\,b,c}`, []string{"a,b", "c"}},
+		{`\{a,b\}/{\*,?}`, []string{`\{a,b}/\*`, `\{a,b}/?`}},
+		{"{a,@}(b)", []string{"a\\(b)", "@\\(b)"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expanded, err := ExpandBraces(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.expected, expanded)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := ExpandBraces("{a,[b}")
+		is.New(t).True(err != nil) // expected an error
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		t.Parallel()
+		_, err := ExpandBraces(strings.Repeat("{0,1,2,3,4,5,6,7,8,9}", 5))
+		is.New(t).True(errors.Is(err, errTooManyExpansions))
+	})
+}
+
+func TestStaticPrefixes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"a/*.txt", []string{"a"}},
+		{"{src,pkg}/**/*.go", []string{"pkg", "src"}},
+		{"{a,a/b,c/*}/*", []string{"a", "c"}},
+		{"{a,*}/b", []string{"."}},
+		{"/{a,b/c}/*", []string{"/a", "/b/c"}},
+		{"{a,b}.txt", []string{"a.txt", "b.txt"}},
+		{strings.Repeat("{0,1,2,3,4,5,6,7,8,9}", 5), []string{"."}},
+		{"a/{1..64}/*", expandedPrefixes("a/", 64)},
+		{"a/{1..65}/*", []string{"a"}},
+		{"{a,a/{1..100}}/*", []string{"a"}},
+		{"{-,.,a}/*", []string{"."}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			prefixes, err := staticPrefixes(testCase.pattern, false)
+			is.NoErr(err)
+			is.Equal(testCase.expected, prefixes)
+		})
+	}
+}
+
+func TestGlobWalksStaticPrefixes(t *testing.T) {
+	t.Parallel()
+
+	fsys := &countingFS{FS: fstest.MapFS{
+		"pkg/a.go":          {},
+		"pkg/b/c.go":        {},
+		"src/d.go":          {},
+		"src/e.txt":         {},
+		"vendor/x/f.go":     {},
+		"node_modules/g.go": {},
+	}}
+
+	is := is.New(t)
+	matches, err := Glob("{src,pkg,nope}/**/*.go", WithFs(fsys))
+	is.NoErr(err)
+	is.Equal([]string{"pkg/b/c.go"}, matches)
+	is.Equal(int64(3), fsys.readDirs.Load()) // pkg, pkg/b and src
+
+	t.Run("same order as a single walk", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		expected, err := Glob("**/*.go", WithFs(fsys))
+		is.NoErr(err)
+		matches, err := Glob("{vendor,src,pkg,node_modules}/**", WithFs(fsys), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return strings.HasSuffix(path, ".go"), nil
+		}))
+		is.NoErr(err)
+		is.Equal(expected, matches)
+	})
+
+	t.Run("many prefixes", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var stats Stats
+		matches, err := Glob("{1..9999}", WithFs(fstest.MapFS{
+			"1":  {},
+			"20": {},
+			"x":  {},
+		}), WithStats(&stats))
+		is.NoErr(err)
+		is.Equal([]string{"1", "20"}, matches)
+		is.Equal(int64(2), stats.StatCalls) // should not stat every prefix
+	})
+}
+
+// expandedPrefixes returns the prefix followed by each number up to n, in the
+// order staticPrefixes sorts them.
+func expandedPrefixes(prefix string, n int) []string {
+	prefixes := make([]string, n)
+	for i := range prefixes {
+		prefixes[i] = prefix + strconv.Itoa(i+1)
+	}
+	slices.Sort(prefixes)
+	return prefixes
+}
@@ -0,0 +1,323 @@
+package fileglob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Explanation is a step-by-step report of how Glob handles a single path.
+type Explanation struct {
+	// Options are the options resolved for the pattern.
+	Options Options `json:"options"`
+	// Path is the path relative to the root of the file system.
+	Path string `json:"path"`
+	// Steps are the steps Glob goes through, up to the one that decided
+	// whether the path is matched.
+	Steps []ExplanationStep `json:"steps"`
+	// Matched is whether Glob returns the path.
+	Matched bool `json:"matched"`
+}
+
+// ExplanationStep is a single step of an Explanation.
+type ExplanationStep struct {
+	// Step is what is checked, like "static prefix" or "filter".
+	Step string `json:"step"`
+	// Detail is a human readable description of the outcome.
+	Detail string `json:"detail"`
+	// OK is whether the step passed. The last step that did not pass is the
+	// one that excluded the path.
+	OK bool `json:"ok"`
+}
+
+// String returns the explanation as a human readable report, one step per
+// line.
+func (e Explanation) String() string {
+	var sb strings.Builder
+	for _, step := range e.Steps {
+		mark := "ok  "
+		if !step.OK {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(&sb, "%s %s: %s\n", mark, step.Step, step.Detail)
+	}
+	if e.Matched {
+		fmt.Fprintf(&sb, "%q is matched\n", e.Path)
+	} else {
+		fmt.Fprintf(&sb, "%q is not matched\n", e.Path)
+	}
+	return sb.String()
+}
+
+func (e *Explanation) step(ok bool, step, format string, args ...any) bool {
+	e.Steps = append(e.Steps, ExplanationStep{
+		Step:   step,
+		Detail: fmt.Sprintf(format, args...),
+		OK:     ok,
+	})
+	return ok
+}
+
+// Explain reports why Glob, given the same pattern and options, would or
+// would not return the given path, which is expected in the same form as
+// Glob returns its matches.
+//
+// It goes through the same steps as Glob, but only looks at the path and its
+// parent directories instead of walking the file system.
+func Explain(pattern, name string, opts ...OptFunc) (Explanation, error) { //nolint:funlen,cyclop
+	options, pattern, err := resolve(pattern, opts)
+	if err != nil {
+		return Explanation{}, err
+	}
+	resolved, err := options.resolved(pattern)
+	if err != nil {
+		return Explanation{}, err
+	}
+	matcher, err := options.newMatcher(pattern)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	e := Explanation{Options: resolved}
+	e.step(true, "pattern", "%q resolves to %q", options.pattern, pattern)
+
+	name = filepath.ToSlash(name)
+	if resolved.Prefix == "" {
+		e.Path = path.Clean(name)
+		e.step(true, "fs prefix", "path is relative to the file system (%s)", resolved.FS)
+	} else {
+		rel, ok := strings.CutPrefix(name, resolved.Prefix)
+		e.Path = path.Clean(rel)
+		if !e.step(ok, "fs prefix", "%q is stripped from the path, relative to the file system (%s)", resolved.Prefix, resolved.FS) {
+			e.Path = name
+			return e, nil
+		}
+	}
+
+	i := slices.IndexFunc(resolved.StaticPrefixes, func(prefix string) bool {
+		return isInside(e.Path, prefix)
+	})
+	if i < 0 {
+		e.step(false, "static prefix", "walking starts at %s", quoteAll(resolved.StaticPrefixes))
+		return e, nil
+	}
+	prefix := resolved.StaticPrefixes[i]
+	e.step(true, "static prefix", "walking starts at %q", prefix)
+
+	if options.matcher == nil {
+		explainSegments(&e, options, pattern)
+	}
+
+	info, err := lstat(options.fs, e.Path, prefix)
+	if isNotExist(err) {
+		e.step(false, "exists", "%q does not exist", e.Path)
+		return e, nil
+	}
+	if err != nil {
+		return e, fmt.Errorf("explain %s: %w", e.Path, err)
+	}
+	e.step(true, "exists", "%q is a %s", e.Path, describeMode(info.Mode()))
+
+	// check the parent directories the same way they are visited by Glob
+	inherited := false
+	for _, dir := range parentDirs(prefix, e.Path) {
+		info, err := lstat(options.fs, dir, prefix)
+		if err != nil {
+			return e, fmt.Errorf("explain %s: %w", dir, err)
+		}
+		ok, err := options.explainEnter(dir, fs.FileInfoToDirEntry(info))
+		if err != nil {
+			return e, err
+		}
+		if !e.step(ok, "directory filter", "%q is %s", dir, pruned(ok)) {
+			return e, nil
+		}
+		if !inherited && !options.matchDirectoriesDirectly && matcher.Match(dir) {
+			inherited = e.step(true, "directory mode", "%q matches the pattern, so MatchDirectoryIncludesContents includes everything inside of it", dir)
+		}
+	}
+
+	d := fs.FileInfoToDirEntry(info)
+	if d.IsDir() {
+		ok, err := options.explainEnter(e.Path, d)
+		if err != nil {
+			return e, err
+		}
+		if !e.step(ok, "directory filter", "%q is %s", e.Path, pruned(ok)) {
+			return e, nil
+		}
+	}
+
+	if !inherited {
+		ok := matcher.Match(e.Path)
+		if !e.step(ok, "match", "%q %s the pattern", e.Path, matchesOrNot(ok)) {
+			return e, nil
+		}
+	}
+	if d.IsDir() && !options.matchDirectoriesDirectly {
+		e.step(false, "directory mode", "MatchDirectoryIncludesContents returns what is inside of directories instead of them, use MatchDirectoryAsFile to match %q itself", e.Path)
+		return e, nil
+	}
+
+	keep, err := options.keepSingle(e.Path, d)
+	if err != nil {
+		return e, fmt.Errorf("filter %s: %w", e.Path, err)
+	}
+	if !e.step(keep, "filter", "%q %s", e.Path, keptOrNot(keep)) {
+		return e, nil
+	}
+
+	if options.limit > 0 {
+		e.step(true, "limit", "WithLimit(%d) only returns it if it is among the first matches", options.limit)
+	}
+	e.Matched = true
+	return e, nil
+}
+
+// explainSegments adds a step for each path element of the pattern, matched
+// against the corresponding element of the path. A "**" matches any number of
+// path elements, so the rest of the pattern is matched against the rest of the
+// path at once.
+//
+// These steps are informational, as Glob always matches whole paths.
+func explainSegments(e *Explanation, options *globOptions, pattern string) {
+	patterns := strings.Split(pattern, separatorString)
+	names := strings.Split(e.Path, separatorString)
+	for i, segment := range patterns {
+		p, err := parsePattern(segment, options.extendedGlob)
+		if err != nil {
+			// path separators inside of braces, and such
+			e.step(true, "segment", "%q can not be matched by itself", segment)
+			return
+		}
+		if slices.ContainsFunc(flatten(p), hasSuper) {
+			rest := strings.Join(patterns[i:], separatorString)
+			target := strings.Join(names[min(i, len(names)):], separatorString)
+			matcher, err := options.compile(rest)
+			ok := err == nil && matcher.Match(target)
+			e.step(ok, "segment", "%q matches across path elements, and %s %q", rest, matchesOrNot(ok), target)
+			return
+		}
+		if i >= len(names) {
+			e.step(false, "segment", "%q has no path element left to match", segment)
+			return
+		}
+		matcher, err := options.compile(segment)
+		ok := err == nil && matcher.Match(names[i])
+		e.step(ok, "segment", "%q %s %q", segment, matchesOrNot(ok), names[i])
+	}
+	if len(names) > len(patterns) {
+		e.step(true, "segment", "the path continues below %q", strings.Join(names[:len(patterns)], separatorString))
+	}
+}
+
+// explainEnter is like enter, for a directory that is not found through a
+// walk, in which case fs.SkipDir and fs.SkipAll mean that it is pruned.
+func (opts *globOptions) explainEnter(path string, d fs.DirEntry) (bool, error) {
+	ok, err := opts.enter(path, d)
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("directory filter %s: %w", path, err)
+	}
+	return ok, nil
+}
+
+// parentDirs returns the directories from prefix down to the parent of name,
+// which is expected to be inside prefix.
+func parentDirs(prefix, name string) []string {
+	if name == prefix {
+		return nil
+	}
+	rest := name
+	if prefix != "." {
+		rest = strings.TrimPrefix(name, prefix+separatorString)
+	}
+	dirs := []string{prefix}
+	elems := strings.Split(rest, separatorString)
+	for _, elem := range elems[:len(elems)-1] {
+		dirs = append(dirs, path.Join(dirs[len(dirs)-1], elem))
+	}
+	return dirs
+}
+
+// lstat returns the information about the named file the way the walk sees
+// it: symbolic links are followed for the static prefix only, if the file
+// system allows not following them.
+func lstat(fsys fs.FS, name, prefix string) (fs.FileInfo, error) {
+	if lfs, ok := fsys.(fs.ReadLinkFS); ok && name != prefix {
+		return lfs.Lstat(name) //nolint:wrapcheck
+	}
+	return fs.Stat(fsys, name) //nolint:wrapcheck
+}
+
+// isInside reports whether name is dir or inside of it.
+func isInside(name, dir string) bool {
+	return dir == "." || name == dir || strings.HasPrefix(name, strings.TrimSuffix(dir, separatorString)+separatorString)
+}
+
+// quoteAll quotes and joins the given strings.
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// hasSuper reports whether the node is or contains a SuperStar.
+func hasSuper(n Node) bool {
+	var branches []Pattern
+	switch n := n.(type) {
+	case SuperStar:
+		return true
+	case Alternatives:
+		branches = n.Branches
+	case ExtGlob:
+		branches = n.Branches
+	}
+	for _, branch := range branches {
+		if slices.ContainsFunc(flatten(branch), hasSuper) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeMode(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "file of type " + mode.Type().String()
+	}
+}
+
+func matchesOrNot(ok bool) string {
+	if ok {
+		return "matches"
+	}
+	return "does not match"
+}
+
+func pruned(entered bool) string {
+	if entered {
+		return "entered"
+	}
+	return "pruned by a directory filter"
+}
+
+func keptOrNot(keep bool) string {
+	if keep {
+		return "passes all filters"
+	}
+	return "is excluded by a filter set with WithFilter or WithFilters"
+}
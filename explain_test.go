@@ -0,0 +1,142 @@
+package fileglob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"dist/foo/bar.tar.gz": {Data: []byte("gz")},
+		"dist/foo/bar.zip":    {Data: []byte("zip")},
+		"dist/skip/baz.zip":   {Data: []byte("zip")},
+		"dist/empty.zip":      {},
+		"docs/a.md":           {Data: []byte("md")},
+	}
+	skip := WithDirFilter(func(_ string, d fs.DirEntry) (bool, error) {
+		return d.Name() != "skip", nil
+	})
+
+	testCases := []struct {
+		name     string
+		pattern  string
+		path     string
+		opts     []OptFunc
+		matched  bool
+		lastStep string
+	}{
+		{"wrong extension", "dist/*/*.zip", "dist/foo/bar.tar.gz", nil, false, "match"},
+		{"match", "dist/*/*.zip", "dist/foo/bar.zip", nil, true, "filter"},
+		{"directory contents", "dist", "dist/foo/bar.zip", nil, true, "filter"},
+		{"directory", "dist/*", "dist/foo", nil, false, "directory mode"},
+		{"directory as file", "dist/*", "dist/foo", []OptFunc{MatchDirectoryAsFile}, true, "filter"},
+		{"directory filter", "dist/**/*.zip", "dist/skip/baz.zip", []OptFunc{skip}, false, "directory filter"},
+		{"filter", "dist/*.zip", "dist/empty.zip", []OptFunc{WithFilters(SizeAtLeast(1))}, false, "filter"},
+		{"static prefix", "docs/*.md", "dist/foo/bar.zip", nil, false, "static prefix"},
+		{"missing", "dist/*.zip", "dist/nope.zip", nil, false, "exists"},
+		{"limit", "**/*.zip", "./dist/foo/bar.zip", []OptFunc{WithLimit(1)}, true, "limit"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			e, err := Explain(testCase.pattern, testCase.path, append([]OptFunc{WithFs(fsys)}, testCase.opts...)...)
+			is.NoErr(err)
+			is.Equal(testCase.matched, e.Matched)
+			is.Equal(testCase.lastStep, e.Steps[len(e.Steps)-1].Step)
+		})
+	}
+
+	t.Run("agrees with glob", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var paths []string
+		is.NoErr(fs.WalkDir(fsys, ".", func(path string, _ fs.DirEntry, err error) error {
+			paths = append(paths, path)
+			return err
+		}))
+		for _, pattern := range []string{"dist", "dist/*", "**/*.zip", "*/{foo,skip}", "{docs,dist/foo}/*", "d*/**", "docs/a.md"} {
+			for _, opts := range [][]OptFunc{nil, {MatchDirectoryAsFile}, {skip}, {WithFilters(SizeAtLeast(3))}} {
+				opts = append([]OptFunc{WithFs(fsys)}, opts...)
+				matches, err := Glob(pattern, opts...)
+				is.NoErr(err)
+				for _, path := range paths {
+					e, err := Explain(pattern, path, opts...)
+					is.NoErr(err)
+					is.Equal(slices.Contains(matches, path), e.Matched) // should agree with Glob
+				}
+			}
+		}
+	})
+
+	t.Run("segments", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		e, err := Explain("dist/*/*.zip", "dist/foo/bar.tar.gz", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal(`ok   pattern: "dist/*/*.zip" resolves to "dist/*/*.zip"
+ok   fs prefix: path is relative to the file system (fstest.MapFS)
+ok   static prefix: walking starts at "dist"
+ok   segment: "dist" matches "dist"
+ok   segment: "*" matches "foo"
+FAIL segment: "*.zip" does not match "bar.tar.gz"
+ok   exists: "dist/foo/bar.tar.gz" is a file
+ok   directory filter: "dist" is entered
+ok   directory filter: "dist/foo" is entered
+FAIL match: "dist/foo/bar.tar.gz" does not match the pattern
+"dist/foo/bar.tar.gz" is not matched
+`, e.String())
+	})
+
+	t.Run("super star", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		e, err := Explain("dist/**/*.zip", "dist/foo/bar.zip", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal(ExplanationStep{
+			Step:   "segment",
+			Detail: `"**/*.zip" matches across path elements, and matches "foo/bar.zip"`,
+			OK:     true,
+		}, e.Steps[4])
+	})
+
+	t.Run("fs prefix", func(t *testing.T) {
+		t.Parallel()
+		if isWindows() {
+			t.Skip("absolute paths differ on windows")
+		}
+		is := is.New(t)
+		e, err := Explain("/dist/*", "dist/foo", MaybeRootFS)
+		is.NoErr(err)
+		is.True(!e.Matched) // should not match
+		is.Equal("fs prefix", e.Steps[len(e.Steps)-1].Step)
+	})
+
+	t.Run("below a file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		// opening a path below a file fails with ENOTDIR on disk, which
+		// means that it does not exist as well
+		dir := t.TempDir()
+		is.NoErr(os.WriteFile(filepath.Join(dir, "a"), nil, 0o600))
+		e, err := Explain("a/*", "a/b", WithFs(os.DirFS(dir)))
+		is.NoErr(err)
+		is.True(!e.Matched)
+		is.Equal("exists", e.Steps[len(e.Steps)-1].Step)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		_, err := Explain("dist/[", "dist/foo", WithFs(fsys))
+		is.New(t).True(err != nil) // expected an error
+	})
+}
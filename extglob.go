@@ -0,0 +1,505 @@
+package fileglob
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExtendedGlob enables the extended glob operators of bash, which match
+// patterns within a single path element:
+//
+//   - !(a|b) matches anything except a or b
+//   - @(a|b) matches exactly one of a or b
+//   - ?(a|b) matches zero or one of a or b
+//   - *(a|b) matches zero or more of a or b
+//   - +(a|b) matches one or more of a or b
+//
+// The patterns inside of an operator can contain any other matcher, like
+// "!(*_test).go". Without this option, those are matched as they are written.
+func ExtendedGlob(opts *globOptions) {
+	opts.extendedGlob = true
+}
+
+// ExtGlob is an extended glob operator, enabled by ExtendedGlob.
+type ExtGlob struct {
+	// Op is one of '!', '@', '?', '*' or '+'.
+	Op       byte
+	Branches []Pattern
+}
+
+func (e ExtGlob) String() string {
+	var sb strings.Builder
+	e.write(&sb)
+	return sb.String()
+}
+
+func (e ExtGlob) write(sb *strings.Builder) {
+	sb.WriteByte(e.Op)
+	sb.WriteByte('(')
+	for i, branch := range e.Branches {
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		branch.write(sb, "|)")
+	}
+	sb.WriteByte(')')
+}
+
+func (e ExtGlob) Describe() string {
+	branches := make([]string, len(e.Branches))
+	for i, branch := range e.Branches {
+		branches[i] = fmt.Sprintf("%q", branch.String())
+	}
+	which := strings.Join(branches, " or ")
+	switch e.Op {
+	case '!':
+		return "anything except " + which
+	case '?':
+		return "zero or one of " + which
+	case '*':
+		return "zero or more of " + which
+	case '+':
+		return "one or more of " + which
+	default:
+		return "exactly one of " + which
+	}
+}
+
+func (ExtGlob) node() {}
+
+// isExtGlobOp reports whether c starts an extended glob operator, when
+// followed by a "(".
+func isExtGlobOp(c byte) bool {
+	return strings.IndexByte("!@?*+", c) >= 0
+}
+
+var errUnclosedExtGlob = errors.New("unclosed extended glob operator")
+
+// extParser parses patterns with extended glob operators. Everything else is
+// parsed like gobwas does.
+type extParser struct {
+	s string
+	i int
+	// operators is false to parse patterns without extended glob operators,
+	// to match those with extMatcher instead of gobwas.
+	operators bool
+}
+
+func parseExtended(pattern string) (Pattern, error) {
+	return parseWith(pattern, true)
+}
+
+func parseWith(pattern string, operators bool) (Pattern, error) {
+	p := &extParser{s: pattern, operators: operators}
+	parsed, err := p.pattern("")
+	if err != nil {
+		return Pattern{}, err
+	}
+	if p.i < len(p.s) {
+		return Pattern{}, fmt.Errorf("unexpected %q at %d", p.s[p.i], p.i)
+	}
+	return parsed, nil
+}
+
+// pattern parses up to the end of the input or the first of the given closing
+// characters, which are the ones of the innermost group.
+func (p *extParser) pattern(closers string) (Pattern, error) { //nolint:funlen,cyclop
+	parsed := Pattern{Segments: []Segment{{}}}
+	// consecutive text is collected here and added as a single literal, so
+	// long runs of it are not copied once per character
+	var text strings.Builder
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		last := &parsed.Segments[len(parsed.Segments)-1]
+		last.Nodes = append(last.Nodes, Literal{Text: text.String()})
+		text.Reset()
+	}
+	add := func(n Node) {
+		flush()
+		last := &parsed.Segments[len(parsed.Segments)-1]
+		last.Nodes = append(last.Nodes, n)
+	}
+	addText := func(s string) {
+		text.WriteString(s)
+	}
+
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if strings.IndexByte(closers, c) >= 0 {
+			flush()
+			return parsed, nil
+		}
+
+		switch {
+		case c == '\\':
+			p.i++
+			if p.i < len(p.s) {
+				_, size := utf8.DecodeRuneInString(p.s[p.i:])
+				addText(p.s[p.i : p.i+size])
+				p.i += size
+			}
+		case c == separatorRune:
+			if closers == "|)" {
+				return Pattern{}, errors.New("path separator inside of extended glob operator")
+			}
+			flush()
+			parsed.Segments = append(parsed.Segments, Segment{})
+			p.i++
+		case p.operators && isExtGlobOp(c) && p.i+1 < len(p.s) && p.s[p.i+1] == '(':
+			p.i += 2
+			ext := ExtGlob{Op: c}
+			for {
+				branch, err := p.pattern("|)")
+				if err != nil {
+					return Pattern{}, err
+				}
+				ext.Branches = append(ext.Branches, branch)
+				if p.i >= len(p.s) {
+					return Pattern{}, errUnclosedExtGlob
+				}
+				p.i++
+				if p.s[p.i-1] == ')' {
+					break
+				}
+			}
+			add(ext)
+		case c == '*':
+			if p.i+1 < len(p.s) && p.s[p.i+1] == '*' {
+				add(SuperStar{})
+				p.i += 2
+				continue
+			}
+			add(Star{})
+			p.i++
+		case c == '?':
+			add(AnyChar{})
+			p.i++
+		case c == '[':
+			class, err := p.class()
+			if err != nil {
+				return Pattern{}, err
+			}
+			add(class)
+		case c == '{':
+			p.i++
+			var alt Alternatives
+			for {
+				branch, err := p.pattern(",}")
+				if err != nil {
+					return Pattern{}, err
+				}
+				alt.Branches = append(alt.Branches, branch)
+				// like gobwas, braces that are never closed end with the
+				// pattern
+				if p.i >= len(p.s) {
+					break
+				}
+				p.i++
+				if p.s[p.i-1] == '}' {
+					break
+				}
+			}
+			add(alt)
+		default:
+			_, size := utf8.DecodeRuneInString(p.s[p.i:])
+			addText(p.s[p.i : p.i+size])
+			p.i += size
+		}
+	}
+	if closers == "|)" {
+		return Pattern{}, errUnclosedExtGlob
+	}
+	flush()
+	return parsed, nil
+}
+
+// class parses a character class, which is either a range, whose ends are
+// never escaped, or a list of characters.
+func (p *extParser) class() (CharClass, error) {
+	p.i++ // [
+	var class CharClass
+	if p.i < len(p.s) && p.s[p.i] == '!' {
+		class.Negated = true
+		p.i++
+	}
+
+	lo, size := utf8.DecodeRuneInString(p.s[p.i:])
+	if p.i+size < len(p.s) && p.s[p.i+size] == '-' {
+		p.i += size + 1
+		hi, size := utf8.DecodeRuneInString(p.s[p.i:])
+		p.i += size
+		if p.i >= len(p.s) || p.s[p.i] != ']' {
+			return class, errors.New("expected close range character")
+		}
+		p.i++
+		if hi < lo {
+			return class, fmt.Errorf("hi character '%c' should be greater than lo '%c'", hi, lo)
+		}
+		class.Lo, class.Hi = lo, hi
+		return class, nil
+	}
+
+	var chars strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if c == ']' {
+			p.i++
+			if chars.Len() == 0 {
+				return class, errors.New("could not parse range")
+			}
+			class.Chars = chars.String()
+			return class, nil
+		}
+		if c == '\\' && p.i+1 < len(p.s) {
+			p.i++
+		}
+		_, size := utf8.DecodeRuneInString(p.s[p.i:])
+		chars.WriteString(p.s[p.i : p.i+size])
+		p.i += size
+	}
+	return class, errors.New("unexpected end of input")
+}
+
+// extMatcher matches paths against a pattern parsed by parseWith.
+type extMatcher struct {
+	seq extSeq
+	// ids is the number of sequences and nodes, which are memoized by id
+	ids int
+}
+
+// extSeq is a sequence of nodes compiled for extMatcher.
+type extSeq struct {
+	id    int
+	nodes []extNode
+}
+
+// extNode is a node compiled for extMatcher. Alternatives and extended globs
+// have branches, and others are matched as the node is.
+type extNode struct {
+	id       int
+	node     Node
+	branches []extSeq
+}
+
+func newExtMatcher(p Pattern) extMatcher {
+	m := extMatcher{}
+	m.seq = m.compile(p)
+	return m
+}
+
+// compile assigns an id to each sequence and node of the pattern.
+func (m *extMatcher) compile(p Pattern) extSeq {
+	seq := extSeq{id: m.ids}
+	m.ids++
+	for _, n := range flatten(p) {
+		node := extNode{id: m.ids, node: n}
+		m.ids++
+		var branches []Pattern
+		switch n := n.(type) {
+		case Alternatives:
+			branches = n.Branches
+		case ExtGlob:
+			branches = n.Branches
+		}
+		for _, branch := range branches {
+			node.branches = append(node.branches, m.compile(branch))
+		}
+		seq.nodes = append(seq.nodes, node)
+	}
+	return seq
+}
+
+func (m extMatcher) Match(s string) bool {
+	match := &extMatch{s: s, stride: len(s) + 1, memo: map[int][]int{}}
+	_, found := slices.BinarySearch(match.seq(m.seq, 0), len(s))
+	return found
+}
+
+// flatten returns the nodes of all segments of the pattern, with literal path
+// separators in between.
+func flatten(p Pattern) []Node {
+	var nodes []Node
+	for i, segment := range p.Segments {
+		if i > 0 {
+			nodes = append(nodes, Literal{Text: separatorString})
+		}
+		nodes = append(nodes, segment.Nodes...)
+	}
+	return nodes
+}
+
+// extMatch matches a string, memoizing the offsets where each sequence and
+// node can end when starting from each offset. Trying every way to match
+// like a backtracking matcher would take exponential time for patterns like
+// "*a*a*a*b", while this takes polynomial time.
+type extMatch struct {
+	s      string
+	stride int
+	memo   map[int][]int
+}
+
+// seq returns the sorted offsets where the sequence can end, when it starts
+// at i.
+func (m *extMatch) seq(q extSeq, i int) []int {
+	key := q.id*m.stride + i
+	if ends, ok := m.memo[key]; ok {
+		return ends
+	}
+	ends := []int{i}
+	for _, n := range q.nodes {
+		ends = m.step(n, ends)
+		if len(ends) == 0 {
+			break
+		}
+	}
+	m.memo[key] = ends
+	return ends
+}
+
+// step returns the sorted offsets where the node can end, when it starts at
+// any of the given sorted offsets.
+//
+// The ends of stars are found for all of them at once, in linear time, as a
+// star can end wherever one starting later in the same path element can.
+// Otherwise, patterns like "***" would take quadratic time per star.
+func (m *extMatch) step(n extNode, starts []int) []int {
+	switch n.node.(type) {
+	case SuperStar:
+		return m.node(n, starts[0])
+	case Star:
+		var ends []int
+		for _, j := range starts {
+			if len(ends) == 0 || j > ends[len(ends)-1] {
+				ends = append(ends, m.elementEnds(j)...)
+			}
+		}
+		return ends
+	}
+
+	var ends []int
+	for _, j := range starts {
+		ends = append(ends, m.node(n, j)...)
+	}
+	return sortedSet(ends)
+}
+
+// node returns the sorted offsets where the node can end, when it starts at
+// i.
+func (m *extMatch) node(n extNode, i int) []int {
+	key := n.id*m.stride + i
+	if ends, ok := m.memo[key]; ok {
+		return ends
+	}
+	ends := m.match(n, i)
+	m.memo[key] = ends
+	return ends
+}
+
+func (m *extMatch) match(n extNode, i int) []int { //nolint:cyclop
+	s := m.s
+	switch node := n.node.(type) {
+	case Literal:
+		if strings.HasPrefix(s[i:], node.Text) {
+			return []int{i + len(node.Text)}
+		}
+	case AnyChar:
+		if r, size := utf8.DecodeRuneInString(s[i:]); size > 0 && r != separatorRune {
+			return []int{i + size}
+		}
+	case CharClass:
+		if r, size := utf8.DecodeRuneInString(s[i:]); size > 0 && node.matches(r) {
+			return []int{i + size}
+		}
+	case Star:
+		return m.elementEnds(i)
+	case SuperStar:
+		ends := make([]int, 0, len(s)-i+1)
+		for j := i; j <= len(s); j++ {
+			if j == len(s) || utf8.RuneStart(s[j]) {
+				ends = append(ends, j)
+			}
+		}
+		return ends
+	case Alternatives:
+		return m.branches(n, i)
+	case ExtGlob:
+		return m.extGlob(node.Op, n, i)
+	}
+	return nil
+}
+
+// elementEnds returns the offsets from i up to the end of the path element.
+func (m *extMatch) elementEnds(i int) []int {
+	ends := []int{i}
+	for j := i; j < len(m.s) && m.s[j] != separatorRune; {
+		_, size := utf8.DecodeRuneInString(m.s[j:])
+		j += size
+		ends = append(ends, j)
+	}
+	return ends
+}
+
+// branches returns the offsets where any of the branches of the node can end.
+// Branches starting with a literal that is not there are skipped without
+// being memoized, as there can be thousands of them, like for "{1..9999}".
+func (m *extMatch) branches(n extNode, i int) []int {
+	var ends []int
+	for _, branch := range n.branches {
+		if len(branch.nodes) > 0 {
+			if l, ok := branch.nodes[0].node.(Literal); ok && !strings.HasPrefix(m.s[i:], l.Text) {
+				continue
+			}
+		}
+		ends = append(ends, m.seq(branch, i)...)
+	}
+	return sortedSet(ends)
+}
+
+func (m *extMatch) extGlob(op byte, n extNode, i int) []int {
+	switch op {
+	case '!':
+		matched := m.branches(n, i)
+		return slices.DeleteFunc(m.elementEnds(i), func(j int) bool {
+			_, found := slices.BinarySearch(matched, j)
+			return found
+		})
+	case '?':
+		return sortedSet(append(m.branches(n, i), i))
+	case '*', '+':
+		// every repetition must consume something, or they would never end
+		var ends []int
+		if _, found := slices.BinarySearch(m.branches(n, i), i); op == '*' || found {
+			ends = append(ends, i)
+		}
+		seen := map[int]bool{}
+		for queue := []int{i}; len(queue) > 0; queue = queue[1:] {
+			for _, k := range m.branches(n, queue[0]) {
+				if k > queue[0] && !seen[k] {
+					seen[k] = true
+					ends = append(ends, k)
+					queue = append(queue, k)
+				}
+			}
+		}
+		return sortedSet(ends)
+	default:
+		return m.branches(n, i)
+	}
+}
+
+// sortedSet sorts the offsets and removes duplicates.
+func sortedSet(offsets []int) []int {
+	slices.Sort(offsets)
+	return slices.Compact(offsets)
+}
+
+// matches reports whether the class matches r.
+func (c CharClass) matches(r rune) bool {
+	in := strings.ContainsRune(c.Chars, r) || c.Chars == "" && c.Lo <= r && r <= c.Hi
+	return in != c.Negated
+}
@@ -0,0 +1,260 @@
+package fileglob
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gobwas/glob"
+	"github.com/matryer/is"
+)
+
+func TestExtendedGlob(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"!(*_test).go", "main.go", true},
+		{"!(*_test).go", "main_test.go", false},
+		{"!(*_test).go", ".go", true},
+		{"!(a|b)", "a", false},
+		{"!(a|b)", "c", true},
+		{"!(a|b)", "ab", true},
+		{"a/!(b)/c", "a/x/c", true},
+		{"a/!(b)/c", "a/b/c", false},
+		{"a/!(b)/c", "a/x/y/c", false},
+		{"@(a|b).txt", "a.txt", true},
+		{"@(a|b).txt", "ab.txt", false},
+		{"+(ab).txt", "abab.txt", true},
+		{"+(ab).txt", ".txt", false},
+		{"+(ab|).txt", ".txt", true},
+		{"*(ab).txt", ".txt", true},
+		{"*(ab).txt", "aba.txt", false},
+		{"*(a|b)", "abba", true},
+		{"?(a|b)c", "c", true},
+		{"?(a|b)c", "ac", true},
+		{"?(a|b)c", "abc", false},
+		{"@(*.@(go|mod))", "x.go", true},
+		{"@(*.@(go|mod))", "x.sum", false},
+		{"**/+([0-9]).txt", "a/b/12.txt", true},
+		{"**/+([0-9]).txt", "a/1x.txt", false},
+		{"{a,@(b|c)}/*", "c/d", true},
+		{"@({a,b}|c)", "b", true},
+		{`\@(a)`, "@(a)", true},
+		{`@(a\|b|c)`, "a|b", true},
+		{`@(a\)|b)`, "a)", true},
+		{"a(b)", "a(b)", true},
+		{"@(日|本)語", "本語", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%s %s", testCase.pattern, testCase.path), func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			options := compileOptions([]OptFunc{ExtendedGlob}, testCase.pattern)
+			matcher, err := options.compile(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.match, matcher.Match(testCase.path))
+		})
+	}
+}
+
+func TestExtendedGlobLikeGobwas(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{
+		"*", "**", "?", "a*", "*a*b", "a/*/c", "a/**", "a/**/c/d", "**/c", "a**c",
+		"[abc]", "[!abc]", "[a-c]*", "[!a-c]", "[/]", "{a,b}/c", "{a,}", "{a/b,c}",
+		"{a,{b,c}d}", `\*`, `a\?`, "*.{go,mod}", "?/?", "a{b,c}*",
+	}
+	// gobwas matches some patterns, like "?" or "a/**/c", against paths
+	// that are too short for them, like "" or "a/c", so those are left out
+	paths := []string{
+		"a", "b", "c", "d", "/", "ab", "abc", "a/c", "a/b/c", "a/bc/c", "a/",
+		"bd", "cd", "*", "a?", "x.go", "x.mod", "x/y.go", "b/c", "a/b", "acx",
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expected := glob.MustCompile(pattern, separatorRune)
+			p, err := parseExtended(pattern)
+			is.NoErr(err)
+			matcher := newExtMatcher(p)
+			for _, path := range paths {
+				is.Equal(expected.Match(path), matcher.Match(path)) // should match like gobwas
+			}
+		})
+	}
+}
+
+func TestExtendedGlobBacktracking(t *testing.T) {
+	t.Parallel()
+
+	// trying every way to match these would take longer than the test
+	// timeout, as there are exponentially many of them
+	name := strings.Repeat("a", 200)
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*a*a*a*a*a*a*a*a*b", name, false},
+		{"*a*a*a*a*a*a*a*a*a", name, true},
+		{"!(*a*a*a*a*a*a*a*a*b)", name, true},
+		{"+(a|aa|*a)b", name, false},
+		{"{*a*a*a*a*a*a*a*a*b,x}", name, false},
+		{"**/x/**/x/**/x/**/x/**/*.go", strings.Repeat("x/", 100) + "a.go", true},
+		{strings.Repeat("*", 1000) + "b", strings.Repeat("a", 100000), false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			p, err := parseExtended(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.match, newExtMatcher(p).Match(testCase.path))
+		})
+	}
+}
+
+func TestGlobExtendedGlob(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"cmd/main.go":      {},
+		"cmd/main_test.go": {},
+		"pkg/a.go":         {},
+		"pkg/a_test.go":    {},
+		"pkg/doc.md":       {},
+		"@(pkg)/b.go":      {},
+	}
+
+	testCases := []struct {
+		pattern  string
+		opts     []OptFunc
+		expected []string
+	}{
+		{"**/!(*_test).go", []OptFunc{ExtendedGlob}, []string{"@(pkg)/b.go", "cmd/main.go", "pkg/a.go"}},
+		{"{cmd,pkg}/!(*_test).go", []OptFunc{ExtendedGlob}, []string{"cmd/main.go", "pkg/a.go"}},
+		{"@(cmd|pkg)/*.md", []OptFunc{ExtendedGlob}, []string{"pkg/doc.md"}},
+		{"@(pkg)/*", nil, []string{"@(pkg)/b.go"}},
+		{`\@(pkg)/*`, []OptFunc{ExtendedGlob}, []string{"@(pkg)/b.go"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			matches, err := Glob(testCase.pattern, append([]OptFunc{WithFs(fsys)}, testCase.opts...)...)
+			is.NoErr(err)
+			is.Equal(testCase.expected, matches)
+		})
+	}
+
+	t.Run("static prefixes", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("{cmd,@(pkg)}/!(*_test).go", WithFs(fsys), ExtendedGlob)
+		is.NoErr(err)
+		is.Equal([]string{"."}, options.StaticPrefixes)
+
+		options, err = ResolveOptions(`{cmd,\@(pkg)}/!(*_test).go`, WithFs(fsys), ExtendedGlob)
+		is.NoErr(err)
+		is.Equal([]string{"@(pkg)", "cmd"}, options.StaticPrefixes)
+	})
+}
+
+func TestExtendedGlobSyntax(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		is.True(ValidPattern("@(a", ExtendedGlob) != nil)    // unclosed
+		is.True(ValidPattern("@(a/b)", ExtendedGlob) != nil) // separator
+		is.True(ValidPattern("@([a)", ExtendedGlob) != nil)  // class
+		is.NoErr(ValidPattern("@(a"))
+		is.NoErr(ValidPattern("!(a|[bc]|{d,e})f", ExtendedGlob))
+	})
+
+	t.Run("contains matchers", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		is.True(ContainsMatchers("@(a)", ExtendedGlob))
+		is.True(!ContainsMatchers("@(a)"))
+		is.True(!ContainsMatchers(`\@(a)`, ExtendedGlob))
+		is.True(!ContainsMatchers("a(b)", ExtendedGlob))
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		p, err := ParsePattern("src/!(*_test|@(a|b)).go", ExtendedGlob)
+		is.NoErr(err)
+		is.Equal(Pattern{Segments: []Segment{
+			{Nodes: []Node{Literal{Text: "src"}}},
+			{Nodes: []Node{
+				ExtGlob{Op: '!', Branches: []Pattern{
+					{Segments: []Segment{{Nodes: []Node{Star{}, Literal{Text: "_test"}}}}},
+					{Segments: []Segment{{Nodes: []Node{ExtGlob{Op: '@', Branches: []Pattern{
+						{Segments: []Segment{{Nodes: []Node{Literal{Text: "a"}}}}},
+						{Segments: []Segment{{Nodes: []Node{Literal{Text: "b"}}}}},
+					}}}}}},
+				}},
+				Literal{Text: ".go"},
+			}},
+		}}, p)
+		is.Equal("src/!(*_test|@(a|b)).go", p.String())
+		is.Equal(`path element 1: "src"
+path element 2: anything except "*_test" or "@(a|b)", followed by ".go"
+`, p.Describe())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		for _, pattern := range []string{
+			"+(a|b)*(c)?(d)",
+			`@(a\|b|c\))`,
+			`!\(a)`,
+			`*\(a)`,
+			`?\(a)`,
+			"a(b)",
+			"{a,@(b|c)}",
+			`a\/`,
+			`a\/b/c`,
+			`@(a\/b|c)`,
+		} {
+			is := is.New(t)
+			p, err := ParsePattern(pattern, ExtendedGlob)
+			is.NoErr(err)
+			is.Equal(pattern, p.String())
+			again, err := ParsePattern(p.String(), ExtendedGlob)
+			is.NoErr(err)
+			is.Equal(p, again)
+		}
+	})
+
+	t.Run("expand braces", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		expanded, err := ExpandBraces("{a,b}/@(c|d)", ExtendedGlob)
+		is.NoErr(err)
+		is.Equal([]string{"a/@(c|d)", "b/@(c|d)"}, expanded)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		base, rest, err := SplitPattern("a/@(b)/c", ExtendedGlob)
+		is.NoErr(err)
+		is.Equal("a", base)
+		is.Equal("@(b)/c", rest)
+	})
+}
@@ -0,0 +1,68 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// FilterFunc decides whether a walked entry should be kept.
+//
+// The path is slash-separated and relative to the root of the file system
+// being walked, the same way it would be passed to fs.WalkDirFunc.
+// Returning fs.SkipDir or fs.SkipAll has the same effect as in fs.WalkDirFunc,
+// any other error aborts the Glob.
+type FilterFunc func(path string, d fs.DirEntry) (bool, error)
+
+// WithFilter adds a filter every match has to pass to be included in the
+// results. It is evaluated after pattern matching, so it is only called for
+// entries that would otherwise be returned, including the files inside of
+// a matching directory.
+//
+// Multiple filters can be given, and all of them have to keep the entry.
+func WithFilter(filter FilterFunc) OptFunc {
+	return func(opts *globOptions) {
+		opts.filters = append(opts.filters, filter)
+	}
+}
+
+// WithDirFilter adds a filter every directory has to pass to be walked into.
+// A rejected directory is pruned with all its contents, whether it matches the
+// pattern or not.
+//
+// Multiple directory filters can be given, and all of them have to keep the
+// directory.
+func WithDirFilter(filter FilterFunc) OptFunc {
+	return func(opts *globOptions) {
+		opts.dirFilters = append(opts.dirFilters, filter)
+	}
+}
+
+// keep reports whether the given match passes all filters.
+func (opts *globOptions) keep(path string, d fs.DirEntry) (bool, error) {
+	return applyFilters(opts.filters, path, d)
+}
+
+// enter reports whether the given directory passes all directory filters.
+func (opts *globOptions) enter(path string, d fs.DirEntry) (bool, error) {
+	return applyFilters(opts.dirFilters, path, d)
+}
+
+func applyFilters(filters []FilterFunc, path string, d fs.DirEntry) (bool, error) {
+	for _, filter := range filters {
+		ok, err := filter(path, d)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// keepSingle is like keep, but for matches that are not found through a walk,
+// in which case fs.SkipDir and fs.SkipAll simply mean that it is not kept.
+func (opts *globOptions) keepSingle(path string, d fs.DirEntry) (bool, error) {
+	ok, err := opts.keep(path, d)
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return false, nil
+	}
+	return ok, err
+}
@@ -0,0 +1,159 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("files", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**/*", WithFs(testFs(t, []string{
+			"a/b.txt",
+			"a/c.go",
+			"d/e.txt",
+		}, nil)), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return strings.HasSuffix(path, ".txt"), nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/b.txt",
+			"d/e.txt",
+		}, matches)
+	})
+
+	t.Run("files in matching directory", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("a", WithFs(testFs(t, []string{
+			"a/b.txt",
+			"a/c.go",
+			"a/d/e.txt",
+		}, nil)), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return strings.HasSuffix(path, ".txt"), nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/b.txt",
+			"a/d/e.txt",
+		}, matches)
+	})
+
+	t.Run("directories as files", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("[a-z]*", MatchDirectoryAsFile, WithFs(testFs(t, []string{
+			"a/b.txt",
+			"c.txt",
+		}, nil)), WithFilter(func(_ string, d fs.DirEntry) (bool, error) {
+			return d.IsDir(), nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{"a"}, matches)
+	})
+
+	t.Run("prefix is a file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("a/b.txt", WithFs(testFs(t, []string{
+			"a/b.txt",
+		}, nil)), WithFilter(func(string, fs.DirEntry) (bool, error) {
+			return false, nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{}, matches)
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("*", WithFs(testFs(t, []string{
+			"a.txt",
+			"b.go",
+			"bb.txt",
+		}, nil)), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return strings.HasSuffix(path, ".txt"), nil
+		}), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return strings.HasPrefix(path, "b"), nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{"bb.txt"}, matches)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		errFilter := errors.New("filter failed")
+		matches, err := Glob("*", WithFs(testFs(t, []string{
+			"a.txt",
+		}, nil)), WithFilter(func(string, fs.DirEntry) (bool, error) {
+			return false, errFilter
+		}))
+		is.True(errors.Is(err, errFilter))
+		is.Equal(nil, matches)
+	})
+}
+
+func TestWithDirFilter(t *testing.T) {
+	t.Parallel()
+
+	files := []string{
+		"a/b.txt",
+		"a/node_modules/c.txt",
+		"a/d/node_modules/e.txt",
+		"a/d/f.txt",
+	}
+	skipNodeModules := func(_ string, d fs.DirEntry) (bool, error) {
+		return d.Name() != "node_modules", nil
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**/*.txt", WithFs(testFs(t, files, nil)), WithDirFilter(skipNodeModules))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/b.txt",
+			"a/d/f.txt",
+		}, matches)
+	})
+
+	t.Run("matching directory", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("a", WithFs(testFs(t, files, nil)), WithDirFilter(skipNodeModules))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/b.txt",
+			"a/d/f.txt",
+		}, matches)
+	})
+
+	t.Run("matching directory as file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**/node_modules", MatchDirectoryAsFile, WithFs(testFs(t, files, nil)), WithDirFilter(skipNodeModules))
+		is.NoErr(err)
+		is.Equal(nil, matches)
+	})
+
+	t.Run("skip all", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**/*.txt", WithFs(testFs(t, files, nil)), WithDirFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			if path == "a/d" {
+				return false, fs.SkipAll
+			}
+			return true, nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{"a/b.txt"}, matches)
+	})
+}
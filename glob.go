@@ -1,15 +1,22 @@
 package fileglob
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 
 	"github.com/gobwas/glob"
+	"github.com/gobwas/glob/match"
+	"github.com/gobwas/glob/syntax/ast"
+	"github.com/gobwas/glob/syntax/lexer"
 )
 
 const (
@@ -28,9 +35,39 @@ type globOptions struct {
 	prefix string
 
 	pattern string
+
+	filters    []FilterFunc
+	dirFilters []FilterFunc
+
+	sort SortOrder
+
+	limit int
+
+	concurrency int
+
+	stats    *Stats
+	counters *counters
+
+	logger *slog.Logger
+
+	extendedGlob     bool
+	strictDoublestar bool
+	stdlibCompat     bool
+
+	matcher MatcherFunc
+
+	maybeRootFS bool
+
+	// err is the first error of the options, like an environment variable
+	// ExpandEnv could not expand or an invalid Filter, which Glob returns.
+	err error
 }
 
 // OptFunc is a function that allow to customize Glob.
+//
+// The functions working on patterns alone, like ValidPattern or ParsePattern,
+// take them as well, so options that change the syntax of patterns, like
+// ExtendedGlob, or the pattern itself, like ExpandEnv, apply to them too.
 type OptFunc func(opts *globOptions)
 
 // WithFs allows to provide another fs.FS implementation to Glob.
@@ -45,6 +82,13 @@ func WithFs(f fs.FS) OptFunc {
 //
 // Result will also be prepended with the root path or volume.
 func MaybeRootFS(opts *globOptions) {
+	opts.maybeRootFS = true
+	opts.detectRoot()
+}
+
+// detectRoot sets the root directory or volume of the pattern as the file
+// system, if it is an absolute path.
+func (opts *globOptions) detectRoot() {
 	if !filepath.IsAbs(opts.pattern) {
 		return
 	}
@@ -62,12 +106,23 @@ func MaybeRootFS(opts *globOptions) {
 }
 
 // WriteOptions write the current options to the given writer.
+//
+// Deprecated: use ResolveOptions to inspect the options, or WithLogger to
+// trace what Glob does.
 func WriteOptions(w io.Writer) OptFunc {
 	return func(opts *globOptions) {
 		_, _ = fmt.Fprintf(w, "%+v", opts)
 	}
 }
 
+// WithLogger makes Glob log what it does at the debug level: how the pattern
+// was resolved, the directories it enters or prunes, and the matches it finds.
+func WithLogger(logger *slog.Logger) OptFunc {
+	return func(opts *globOptions) {
+		opts.logger = logger
+	}
+}
+
 // MatchDirectoryIncludesContents makes a match on a directory match all
 // files inside it as well.
 //
@@ -85,10 +140,46 @@ func MatchDirectoryAsFile(opts *globOptions) {
 	opts.matchDirectoriesDirectly = true
 }
 
+// WithLimit makes Glob return at most n matches, stopping the walk as soon as
+// they are found. A limit of zero or less means no limit.
+//
+// When combined with WithSort, all matches still have to be found so they can
+// be sorted, and only the first n of them are returned.
+func WithLimit(n int) OptFunc {
+	return func(opts *globOptions) {
+		opts.limit = n
+	}
+}
+
+// WithConcurrency makes Glob walk independent directories with up to n
+// goroutines, which helps on slow or networked file systems.
+//
+// The results are the same, and in the same order, as walking sequentially,
+// which is what happens when n is 1 or less. Filters given to WithFilter and
+// WithDirFilter will be called concurrently, and must be safe to do so.
+func WithConcurrency(n int) OptFunc {
+	return func(opts *globOptions) {
+		opts.concurrency = n
+	}
+}
+
 // QuoteMeta quotes all glob pattern meta characters inside the argument text.
 // For example, QuoteMeta for a pattern `{foo*}` sets the pattern to `\{foo\*\}`.
 func QuoteMeta(opts *globOptions) {
-	opts.pattern = glob.QuoteMeta(opts.pattern)
+	opts.pattern = quoteMeta(opts.pattern)
+}
+
+// quoteMeta is like glob.QuoteMeta, but also quotes the parentheses that start
+// extended glob operators, so the text is static with ExtendedGlob as well.
+func quoteMeta(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(`*?\[]{}(`, s[i]) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
 }
 
 // toNixPath converts the path to the nix style path
@@ -100,99 +191,350 @@ func toNixPath(s string) string {
 // Glob returns all files that match the given pattern in the current directory.
 // If the given pattern indicates an absolute path, it will glob from `/`.
 // If the given pattern starts with `../`, it will resolve to its absolute path and glob from `/`.
+// Bash sequence expressions, like `{1..10}` or `{a..f}`, are expanded to alternatives.
+// POSIX character classes, like `[[:digit:]]`, are supported in brackets.
 func Glob(pattern string, opts ...OptFunc) ([]string, error) { //nolint:funlen,cyclop
 	var matches []string
 
-	if strings.HasPrefix(pattern, "../") {
-		p, err := filepath.Abs(pattern)
-		if err != nil {
-			return matches, fmt.Errorf("failed to resolve pattern: %s: %w", pattern, err)
-		}
-		pattern = filepath.ToSlash(p)
+	counters := newCounters()
+	options, pattern, err := resolve(pattern, opts)
+	if options != nil && options.stats != nil {
+		defer counters.report(options.stats)
 	}
-
-	options := compileOptions(opts, pattern)
-
-	pattern = strings.TrimSuffix(strings.TrimPrefix(options.pattern, options.prefix), separatorString)
-	matcher, err := glob.Compile(pattern, separatorRune)
 	if err != nil {
-		return matches, fmt.Errorf("compile glob pattern: %w", err)
+		return matches, err
 	}
+	options.counters = counters
 
-	prefix, err := staticPrefix(pattern)
+	matcher, err := options.newMatcher(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("cannot determine static prefix: %w", err)
+		return matches, err
 	}
+	prefix := matcher.StaticPrefix()
+
+	options.debug("resolved pattern",
+		slog.String("pattern", options.pattern),
+		slog.String("fs_prefix", options.prefix),
+		slog.String("resolved", pattern),
+		slog.String("static_prefix", prefix),
+	)
 
 	// Check if the file is valid symlink without following it
 	// It works only for valid absolut or relative file paths, in other words, will fail for WithFs() option
-	if patternInfo, err := os.Lstat(pattern); err == nil {
-		if patternInfo.Mode()&os.ModeSymlink == os.ModeSymlink {
+	// An Index must not touch the disk at all, so it is skipped for them.
+	if _, isIndex := options.fs.(*Index); !isIndex {
+		options.counters.statCalls.Add(1)
+		if patternInfo, err := os.Lstat(pattern); err == nil && patternInfo.Mode()&os.ModeSymlink == os.ModeSymlink {
+			keep, err := options.keepSingle(pattern, fs.FileInfoToDirEntry(patternInfo))
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", pattern, err)
+			}
+			if !keep {
+				return []string{}, nil
+			}
+			options.counters.entriesMatched.Add(1)
+			options.debug("pattern is a symlink", slog.String("path", pattern))
 			return cleanFilepaths([]string{pattern}, options.prefix), nil
 		}
 	}
 
-	prefixInfo, err := fs.Stat(options.fs, prefix)
-	if errors.Is(err, fs.ErrNotExist) {
-		if !ContainsMatchers(pattern) {
+	prefixes, err := options.walkedPrefixes(pattern, matcher)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) > 1 {
+		options.debug("walking multiple static prefixes", slog.Any("static_prefixes", prefixes))
+	}
+
+	// only needed for missing prefixes, but the same for all of them
+	static := options.matcher == nil && !containsMatchers(pattern, options.extendedGlob)
+	for _, prefix := range prefixes {
+		found, err := options.globPrefix(pattern, prefix, static, matcher, matches)
+		if err != nil {
+			return found, err
+		}
+		matches = found
+		if options.full(len(matches)) {
+			break
+		}
+	}
+
+	if err := sortMatches(options, matches); err != nil {
+		return nil, err
+	}
+	if options.limit > 0 && len(matches) > options.limit {
+		matches = matches[:options.limit]
+	}
+
+	if options.stdlibCompat && len(matches) == 0 {
+		return nil, nil
+	}
+	return cleanFilepaths(matches, options.prefix), nil
+}
+
+// globPrefix appends to matches what matches the pattern inside of the given
+// static prefix. static is whether the pattern contains no matchers.
+func (opts *globOptions) globPrefix(pattern, prefix string, static bool, matcher Matcher, matches []string) ([]string, error) { //nolint:cyclop
+	opts.counters.statCalls.Add(1)
+	prefixInfo, err := fs.Stat(opts.fs, prefix)
+	if err != nil && opts.stdlibCompat && matcher.Match(prefix) {
+		prefixInfo, err = stdlibLstat(opts.fs, prefix, err)
+	}
+	if isNotExist(err) || err != nil && opts.stdlibCompat {
+		if opts.isSinglePath(static, prefix, matcher) {
 			// glob contains no dynamic matchers so prefix is the file name that
 			// the glob references directly. When the glob explicitly references
 			// a single non-existing file, return an error for the user to check.
-			return []string{}, fmt.Errorf(`matching "%s%s": %w`, options.prefix, prefix, fs.ErrNotExist)
+			return []string{}, fmt.Errorf(`matching "%s%s": %w`, opts.prefix, prefix, fs.ErrNotExist)
 		}
 
-		return []string{}, nil
+		opts.counters.errorsSkipped.Add(1)
+		opts.debug("static prefix does not exist", slog.String("path", prefix))
+		return orEmpty(matches), nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("stat static prefix %s%s: %w", options.prefix, prefix, err)
+		return nil, fmt.Errorf("stat static prefix %s%s: %w", opts.prefix, prefix, err)
 	}
 
 	if !prefixInfo.IsDir() {
 		// if the prefix is a file, it either has to be
 		// the only match, or nothing matches at all
-		if matcher.Match(prefix) {
-			return cleanFilepaths([]string{prefix}, options.prefix), nil
+		if !matcher.Match(prefix) {
+			return orEmpty(matches), nil
 		}
 
-		return []string{}, nil
-	}
-
-	if err := fs.WalkDir(options.fs, prefix, func(path string, info fs.DirEntry, err error) error {
+		keep, err := opts.keepSingle(prefix, fs.FileInfoToDirEntry(prefixInfo))
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("filter %s%s: %w", opts.prefix, prefix, err)
 		}
-
-		// The glob ast from github.com/gobwas/glob only works properly with linux paths
-		path = toNixPath(path)
-		if !matcher.Match(path) {
-			return nil
+		if !keep {
+			return orEmpty(matches), nil
 		}
 
-		if info.IsDir() {
-			if options.matchDirectoriesDirectly {
-				matches = append(matches, path)
-				return nil
-			}
+		opts.counters.entriesMatched.Add(1)
+		opts.debug("match", slog.String("path", prefix))
+		return append(matches, prefix), nil
+	}
 
-			// a direct match on a directory implies that all files inside
-			// match if options.matchFolders is false
-			filesInDir, err := filesInDirectory(options, path)
+	visit := func(path string, info fs.DirEntry, inherited bool, matches []string) ([]string, bool, error) {
+		if info.IsDir() {
+			enter, err := opts.enter(path, info)
 			if err != nil {
-				return err
+				return matches, false, err
+			}
+			if !enter {
+				opts.debug("pruning directory", slog.String("path", path))
+				return matches, false, fs.SkipDir
 			}
+			opts.debug("entering directory", slog.String("path", path), slog.Bool("inherited", inherited))
+		}
+
+		// a direct match on a directory implies that all files inside
+		// match if opts.matchDirectoriesDirectly is false
+		if !inherited && !matcher.Match(path) {
+			return matches, false, nil
+		}
 
-			matches = append(matches, filesInDir...)
-			return fs.SkipDir
+		if info.IsDir() && !opts.matchDirectoriesDirectly {
+			return matches, true, nil
+		}
+
+		keep, err := opts.keep(path, info)
+		if err != nil || !keep {
+			return matches, inherited, err
 		}
 
 		matches = append(matches, path)
+		opts.counters.entriesMatched.Add(1)
+		opts.debug("match", slog.String("path", path))
+		return matches, inherited, nil
+	}
 
-		return nil
-	}); err != nil {
+	if opts.stdlibCompat {
+		visit = pruneBelow(visit, depth(pattern))
+	}
+
+	matches, err = walk(opts, prefix, fs.FileInfoToDirEntry(prefixInfo), visit, matches)
+	if err != nil {
 		return nil, fmt.Errorf("glob failed: %w", err)
 	}
+	return matches, nil
+}
 
-	return cleanFilepaths(matches, options.prefix), nil
+// isNotExist reports whether err means that a file does not exist, including
+// when one of its parents is not a directory.
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+}
+
+// orEmpty returns an empty slice instead of nil, which is what Glob returns
+// when there is nothing to walk.
+func orEmpty(matches []string) []string {
+	if matches == nil {
+		return []string{}
+	}
+	return matches
+}
+
+// Exists reports whether anything matches the given pattern.
+// It accepts the same options as Glob, and stops at the first match.
+func Exists(pattern string, opts ...OptFunc) (bool, error) {
+	matches, err := Glob(pattern, append(slices.Clip(opts), WithLimit(1))...)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(matches) > 0, nil
+}
+
+// First returns the first match of the given pattern.
+// It accepts the same options as Glob, and stops at the first match.
+//
+// If nothing matches, the returned error wraps fs.ErrNotExist.
+func First(pattern string, opts ...OptFunc) (string, error) {
+	matches, err := Glob(pattern, append(slices.Clip(opts), WithLimit(1))...)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf(`matching "%s": %w`, pattern, fs.ErrNotExist)
+	}
+	return matches[0], nil
+}
+
+// resolve compiles the options for the given pattern, and returns the pattern
+// relative to the root of the options file system. The options are returned
+// even if the pattern can not be resolved, once they are compiled.
+func resolve(pattern string, opts []OptFunc) (*globOptions, string, error) {
+	pattern, err := resolveParent(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	options := compileOptions(opts, pattern)
+	if options.err != nil {
+		return options, "", options.err
+	}
+	pattern = strings.TrimSuffix(strings.TrimPrefix(options.pattern, options.prefix), separatorString)
+	if options.matcher != nil {
+		return options, pattern, nil
+	}
+	if err := checkNUL(pattern); err != nil {
+		return options, "", fmt.Errorf("failed to resolve pattern: %w", err)
+	}
+	pattern, err = expandPOSIXClasses(pattern)
+	if err != nil {
+		return options, "", fmt.Errorf("failed to resolve pattern: %w", err)
+	}
+	pattern, err = expandSequences(pattern)
+	if err != nil {
+		return options, "", fmt.Errorf("failed to resolve pattern: %w", err)
+	}
+	if options.strictDoublestar {
+		pattern, err = strictDoublestar(pattern, options.extendedGlob)
+		if err != nil {
+			return options, "", fmt.Errorf("failed to resolve pattern: %w", err)
+		}
+	}
+	return options, pattern, nil
+}
+
+// resolveParent makes a pattern starting with "../" absolute, as it is
+// outside of the current directory.
+func resolveParent(pattern string) (string, error) {
+	if !strings.HasPrefix(pattern, "../") {
+		return pattern, nil
+	}
+	p, err := filepath.Abs(pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve pattern: %s: %w", pattern, err)
+	}
+	return filepath.ToSlash(p), nil
+}
+
+// maxGobwasMatchers is the maximum number of matchers of a pattern compiled
+// by gobwas, which takes cubic time in their number, like 25s for 1000 "?a".
+const maxGobwasMatchers = 64
+
+// compile compiles the pattern, which is relative to the root of the file
+// system, with gobwas. It mismatches some alternatives, like "{a*,?b}/c" for
+// "a/c", and patterns rewritten by StrictDoublestar, so those are matched by
+// extMatcher instead, as well as patterns with too many matchers for gobwas.
+func (opts *globOptions) compile(pattern string) (glob.Glob, error) {
+	if opts.extendedGlob || opts.strictDoublestar || hasTooManyMatchers(pattern) {
+		p, err := parseWith(pattern, opts.extendedGlob)
+		if err != nil {
+			return nil, fmt.Errorf("compile glob pattern: %w", err)
+		}
+		return newExtMatcher(p), nil
+	}
+
+	matcher, err := glob.Compile(pattern, separatorRune)
+	if err != nil {
+		return nil, fmt.Errorf("compile glob pattern: %w", err)
+	}
+	if m, ok := matcher.(match.Matcher); ok && hasMisjudgedLength(m, false) {
+		p, err := parseWith(pattern, false)
+		if err != nil {
+			return nil, fmt.Errorf("compile glob pattern: %w", err)
+		}
+		return newExtMatcher(p), nil
+	}
+	return matcher, nil
+}
+
+// hasTooManyMatchers reports whether the pattern has more matchers than
+// gobwas can compile quickly.
+func hasTooManyMatchers(pattern string) bool {
+	tree, err := ast.Parse(lexer.NewLexer(pattern))
+	return err == nil && countMatchers(tree) > maxGobwasMatchers
+}
+
+// countMatchers returns the number of nodes of the tree that are not static.
+func countMatchers(n *ast.Node) int {
+	count := 0
+	if n.Kind != ast.KindPattern && n.Kind != ast.KindText && n.Kind != ast.KindNothing {
+		count++
+	}
+	for _, child := range n.Children {
+		count += countMatchers(child)
+	}
+	return count
+}
+
+// hasMisjudgedLength reports whether the matcher contains alternatives gobwas
+// gets the length of wrong, where it matters: it takes the length of the
+// first fixed length alternative after ones of any length, like 2 for
+// "{a*,?b}", and then only tries to match that many characters when the
+// alternatives are followed or preceded by something else.
+//
+// Empty alternatives, like "{}" or an unclosed "{", have no length at all,
+// which makes gobwas panic when they follow something of a fixed length.
+func hasMisjudgedLength(m match.Matcher, lengthUsed bool) bool {
+	switch m := m.(type) {
+	case match.AnyOf:
+		length := -1
+		for i, alt := range m.Matchers {
+			if i > 0 && alt.Len() != length {
+				length = -1
+				break
+			}
+			length = alt.Len()
+		}
+		return lengthUsed && m.Len() != length || slices.ContainsFunc(m.Matchers, func(alt match.Matcher) bool {
+			return hasMisjudgedLength(alt, lengthUsed)
+		})
+	case match.BTree:
+		return hasMisjudgedLength(m.Value, true) || hasMisjudgedLength(m.Left, true) || hasMisjudgedLength(m.Right, true)
+	case match.Row:
+		return slices.ContainsFunc(m.Matchers, func(m match.Matcher) bool {
+			return m.Len() == 0 || hasMisjudgedLength(m, true)
+		})
+	default:
+		return false
+	}
 }
 
 func compileOptions(optFuncs []OptFunc, pattern string) *globOptions {
@@ -209,24 +551,24 @@ func compileOptions(optFuncs []OptFunc, pattern string) *globOptions {
 	return opts
 }
 
-func filesInDirectory(options *globOptions, dir string) ([]string, error) {
-	var files []string
+// setErr keeps the first error of the options for Glob to return.
+func (opts *globOptions) setErr(err error) {
+	if opts.err == nil {
+		opts.err = err
+	}
+}
 
-	err := fs.WalkDir(options.fs, dir, func(path string, info fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		path = toNixPath(path)
-		files = append(files, path)
-		return nil
-	})
-	if err != nil {
-		return files, fmt.Errorf("failed to get files in directory: %w", err)
+// debug logs the given message, if there is a logger.
+func (opts *globOptions) debug(msg string, attrs ...slog.Attr) {
+	if opts.logger == nil {
+		return
 	}
-	return files, nil
+	opts.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+// full reports whether the walk can stop because the limit has been reached.
+func (opts *globOptions) full(n int) bool {
+	return opts.limit > 0 && n >= opts.limit && opts.sort == SortNone
 }
 
 func cleanFilepaths(paths []string, prefix string) []string {
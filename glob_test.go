@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/caarlos0/testfs"
 	"github.com/gobwas/glob"
@@ -26,10 +28,29 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		matches, err := Glob("*_test.go", WriteOptions(&w))
 		is.NoErr(err)
 		is.Equal([]string{
+			"bash_test.go",
+			"doublestar_test.go",
+			"env_test.go",
+			"expand_test.go",
+			"explain_test.go",
+			"extglob_test.go",
+			"filter_test.go",
 			"glob_test.go",
+			"globber_test.go",
+			"index_test.go",
+			"matcher_test.go",
+			"metadata_test.go",
+			"options_test.go",
+			"pattern_test.go",
+			"posix_test.go",
 			"prefix_test.go",
+			"sequence_test.go",
+			"sort_test.go",
+			"stats_test.go",
+			"stdlib_test.go",
+			"walk_test.go",
 		}, matches)
-		is.Equal("&{fs:. matchDirectoriesDirectly:false prefix:./ pattern:*_test.go}", w.String())
+		is.Equal("&{fs:. matchDirectoriesDirectly:false prefix:./ pattern:*_test.go filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:false err:<nil>}", w.String())
 	})
 
 	t.Run("real with rootfs", func(t *testing.T) {
@@ -50,11 +71,30 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		matches, err := Glob(pattern, MaybeRootFS, WriteOptions(&w))
 		is.NoErr(err)
 		is.Equal([]string{
+			toNixPath(filepath.Join(wd, "bash_test.go")),
+			toNixPath(filepath.Join(wd, "doublestar_test.go")),
+			toNixPath(filepath.Join(wd, "env_test.go")),
+			toNixPath(filepath.Join(wd, "expand_test.go")),
+			toNixPath(filepath.Join(wd, "explain_test.go")),
+			toNixPath(filepath.Join(wd, "extglob_test.go")),
+			toNixPath(filepath.Join(wd, "filter_test.go")),
 			toNixPath(filepath.Join(wd, "glob_test.go")),
+			toNixPath(filepath.Join(wd, "globber_test.go")),
+			toNixPath(filepath.Join(wd, "index_test.go")),
+			toNixPath(filepath.Join(wd, "matcher_test.go")),
+			toNixPath(filepath.Join(wd, "metadata_test.go")),
+			toNixPath(filepath.Join(wd, "options_test.go")),
+			toNixPath(filepath.Join(wd, "pattern_test.go")),
+			toNixPath(filepath.Join(wd, "posix_test.go")),
 			toNixPath(filepath.Join(wd, "prefix_test.go")),
+			toNixPath(filepath.Join(wd, "sequence_test.go")),
+			toNixPath(filepath.Join(wd, "sort_test.go")),
+			toNixPath(filepath.Join(wd, "stats_test.go")),
+			toNixPath(filepath.Join(wd, "stdlib_test.go")),
+			toNixPath(filepath.Join(wd, "walk_test.go")),
 		}, matches)
 		is.Equal(fmt.Sprintf(
-			"&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s}",
+			"&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}",
 			prefix, prefix, pattern,
 		), w.String())
 	})
@@ -101,7 +141,7 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		is.True(strings.HasSuffix(err.Error(), "file does not exist")) // should have been file does not exist
 		is.Equal([]string{}, matches)
 		is.Equal(fmt.Sprintf(
-			"&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s}",
+			"&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}",
 			prefix, prefix, glob.QuoteMeta(abs),
 		), w.String())
 	})
@@ -130,10 +170,29 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		matches, err := Glob(pattern, MaybeRootFS, WriteOptions(&w))
 		is.NoErr(err)
 		is.Equal([]string{
+			toNixPath(filepath.Join(wd, "bash_test.go")),
+			toNixPath(filepath.Join(wd, "doublestar_test.go")),
+			toNixPath(filepath.Join(wd, "env_test.go")),
+			toNixPath(filepath.Join(wd, "expand_test.go")),
+			toNixPath(filepath.Join(wd, "explain_test.go")),
+			toNixPath(filepath.Join(wd, "extglob_test.go")),
+			toNixPath(filepath.Join(wd, "filter_test.go")),
 			toNixPath(filepath.Join(wd, "glob_test.go")),
+			toNixPath(filepath.Join(wd, "globber_test.go")),
+			toNixPath(filepath.Join(wd, "index_test.go")),
+			toNixPath(filepath.Join(wd, "matcher_test.go")),
+			toNixPath(filepath.Join(wd, "metadata_test.go")),
+			toNixPath(filepath.Join(wd, "options_test.go")),
+			toNixPath(filepath.Join(wd, "pattern_test.go")),
+			toNixPath(filepath.Join(wd, "posix_test.go")),
 			toNixPath(filepath.Join(wd, "prefix_test.go")),
+			toNixPath(filepath.Join(wd, "sequence_test.go")),
+			toNixPath(filepath.Join(wd, "sort_test.go")),
+			toNixPath(filepath.Join(wd, "stats_test.go")),
+			toNixPath(filepath.Join(wd, "stdlib_test.go")),
+			toNixPath(filepath.Join(wd, "walk_test.go")),
 		}, matches)
-		is.Equal(fmt.Sprintf("&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s}", prefix, prefix, abs), w.String())
+		is.Equal(fmt.Sprintf("&{fs:%s matchDirectoriesDirectly:false prefix:%s pattern:%s filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}", prefix, prefix, abs), w.String())
 	})
 
 	t.Run("real with rootfs on relative path", func(t *testing.T) {
@@ -146,10 +205,29 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		matches, err := Glob(pattern, MaybeRootFS, WriteOptions(&w))
 		is.NoErr(err)
 		is.Equal([]string{
+			"bash_test.go",
+			"doublestar_test.go",
+			"env_test.go",
+			"expand_test.go",
+			"explain_test.go",
+			"extglob_test.go",
+			"filter_test.go",
 			"glob_test.go",
+			"globber_test.go",
+			"index_test.go",
+			"matcher_test.go",
+			"metadata_test.go",
+			"options_test.go",
+			"pattern_test.go",
+			"posix_test.go",
 			"prefix_test.go",
+			"sequence_test.go",
+			"sort_test.go",
+			"stats_test.go",
+			"stdlib_test.go",
+			"walk_test.go",
 		}, matches)
-		is.Equal("&{fs:. matchDirectoriesDirectly:false prefix:./ pattern:./*_test.go}", w.String())
+		is.Equal("&{fs:. matchDirectoriesDirectly:false prefix:./ pattern:./*_test.go filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}", w.String())
 	})
 
 	t.Run("real with rootfs on relative path match dir", func(t *testing.T) {
@@ -164,7 +242,7 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		is.Equal([]string{
 			".github",
 		}, matches)
-		is.Equal("&{fs:. matchDirectoriesDirectly:true prefix:./ pattern:.github}", w.String())
+		is.Equal("&{fs:. matchDirectoriesDirectly:true prefix:./ pattern:.github filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}", w.String())
 	})
 
 	t.Run("real with rootfs on relative path match dir", func(t *testing.T) {
@@ -179,7 +257,7 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		is.Equal([]string{
 			".github/workflows",
 		}, matches)
-		is.Equal("&{fs:. matchDirectoriesDirectly:true prefix:./ pattern:.github/workflows/}", w.String())
+		is.Equal("&{fs:. matchDirectoriesDirectly:true prefix:./ pattern:.github/workflows/ filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:true err:<nil>}", w.String())
 	})
 
 	t.Run("simple", func(t *testing.T) {
@@ -202,7 +280,7 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 			"a/d/file1.txt",
 			"a/nope/file1.txt",
 		}, matches)
-		is.Equal(fmt.Sprintf("&{fs:%+v matchDirectoriesDirectly:false prefix:./ pattern:./a/*/*}", fsys), w.String())
+		is.Equal(fmt.Sprintf("&{fs:%+v matchDirectoriesDirectly:false prefix:./ pattern:./a/*/* filters:[] dirFilters:[] sort:0 limit:0 concurrency:0 stats:<nil> counters:<nil> logger:<nil> extendedGlob:false strictDoublestar:false stdlibCompat:false matcher:<nil> maybeRootFS:false err:<nil>}", fsys), w.String())
 	})
 
 	t.Run("single file", func(t *testing.T) {
@@ -492,6 +570,82 @@ func TestGlob(t *testing.T) { //nolint:funlen,maintidx
 		}, matches)
 	})
 
+	t.Run("alternatives of different lengths", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("{a*,?b}/c", WithFs(testFs(t, []string{
+			"./a/c",
+			"./xb/c",
+			"./d/c",
+		}, nil)))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/c",
+			"xb/c",
+		}, matches)
+	})
+
+	t.Run("alternatives gobwas mismatches", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("a/{b*,c}", WithFs(testFs(t, []string{
+			"./a/bxx",
+			"./a/c",
+			"./a/d",
+		}, nil)))
+		is.NoErr(err)
+		is.Equal([]string{
+			"a/bxx",
+			"a/c",
+		}, matches)
+
+		// only those are matched by extMatcher, and others by gobwas
+		options := compileOptions(nil, "")
+		for pattern, misjudged := range map[string]bool{
+			"{a*,?b}/c":              true,
+			"a/{b*,c}":               true,
+			"{a,b}/c":                false,
+			"{ab,?}/c":               false,
+			"{a*,b}":                 false,
+			"{**/*.go,x}":            false,
+			"{src,pkg}/*.go":         false,
+			"0{":                     true,
+			"0{,":                    true,
+			"a{}b":                   true,
+			strings.Repeat("?a", 65): true,
+		} {
+			g, err := options.compile(pattern)
+			is.NoErr(err)
+			_, ext := g.(extMatcher)
+			is.Equal(misjudged, ext) // pattern should be matched by extMatcher
+		}
+	})
+
+	t.Run("empty alternatives", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := testFs(t, []string{"./0", "./ab", "./a"}, nil)
+		matches, err := Glob("0{", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal([]string{"0"}, matches)
+
+		matches, err = Glob("a{}b", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal([]string{"ab"}, matches)
+	})
+
+	t.Run("static prefix inside of a file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := testFs(t, []string{"./a"}, nil)
+		matches, err := Glob("a/b/*", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal([]string{}, matches)
+
+		_, err = Glob("a/b", WithFs(fsys))
+		is.True(errors.Is(err, fs.ErrNotExist))
+	})
+
 	t.Run("symlinks", func(t *testing.T) {
 		t.Parallel()
 		var fsPath string
@@ -538,6 +692,176 @@ func TestQuoteMeta(t *testing.T) {
 	}, matches)
 }
 
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	var w bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	matches, err := Glob("./a/**/*.txt", WithLogger(logger), WithFs(fstest.MapFS{
+		"a/b/1.txt":      {},
+		"a/b/2.go":       {},
+		"a/skip/3.txt":   {},
+		"a/c/d/4.txt":    {},
+		"a/c/d/e/5.json": {},
+	}), WithDirFilter(func(path string, _ fs.DirEntry) (bool, error) {
+		return path != "a/skip", nil
+	}))
+	is.NoErr(err)
+	is.Equal([]string{"a/b/1.txt", "a/c/d/4.txt"}, matches)
+	is.Equal(strings.Join([]string{
+		`level=DEBUG msg="resolved pattern" pattern=./a/**/*.txt fs_prefix=./ resolved=a/**/*.txt static_prefix=a`,
+		`level=DEBUG msg="entering directory" path=a inherited=false`,
+		`level=DEBUG msg="entering directory" path=a/b inherited=false`,
+		`level=DEBUG msg=match path=a/b/1.txt`,
+		`level=DEBUG msg="entering directory" path=a/c inherited=false`,
+		`level=DEBUG msg="entering directory" path=a/c/d inherited=false`,
+		`level=DEBUG msg=match path=a/c/d/4.txt`,
+		`level=DEBUG msg="entering directory" path=a/c/d/e inherited=false`,
+		`level=DEBUG msg="pruning directory" path=a/skip`,
+		"",
+	}, "\n"), w.String())
+}
+
+func TestWithLimit(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"a/1.txt":   {Data: []byte("123")},
+		"a/2.txt":   {Data: []byte("1")},
+		"b/3.txt":   {Data: []byte("12")},
+		"c/d/4.txt": {Data: []byte("1234")},
+	}
+
+	t.Run("stops walking", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var entered []string
+		matches, err := Glob("**.txt", WithFs(fsys), WithLimit(2), WithDirFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			entered = append(entered, path)
+			return true, nil
+		}))
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt", "a/2.txt"}, matches)
+		is.Equal([]string{".", "a"}, entered)
+	})
+
+	t.Run("matching directory", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("{a,b}", WithFs(fsys), WithLimit(3))
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt", "a/2.txt", "b/3.txt"}, matches)
+	})
+
+	t.Run("matching directory as file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("[a-z]", MatchDirectoryAsFile, WithFs(fsys), WithLimit(2))
+		is.NoErr(err)
+		is.Equal([]string{"a", "b"}, matches)
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**.txt", WithFs(fsys), WithLimit(2), WithSort(SortSize))
+		is.NoErr(err)
+		is.Equal([]string{"a/2.txt", "b/3.txt"}, matches)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("**.txt", WithFs(fsys), WithLimit(0))
+		is.NoErr(err)
+		is.Equal(4, len(matches))
+	})
+}
+
+func TestExists(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"a/1.txt": {},
+		"b/2.txt": {},
+	}
+
+	testCases := []struct {
+		pattern string
+		exists  bool
+	}{
+		{"**.txt", true},
+		{"b/*", true},
+		{"a/1.txt", true},
+		{"**.zip", false},
+		{"c/*", false},
+		{"a/2.txt", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			exists, err := Exists(testCase.pattern, WithFs(fsys))
+			is.NoErr(err)
+			is.Equal(testCase.exists, exists)
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Exists("[*", WithFs(fsys))
+		is.True(err != nil) // expected an error
+	})
+}
+
+func TestFirst(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"a/1.txt": {},
+		"b/2.txt": {},
+	}
+
+	t.Run("match", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		first, err := First("*/*.txt", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal("a/1.txt", first)
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		first, err := First("*/*.txt", WithFs(fsys), WithSort(SortBreadthFirst), WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return path != "a/1.txt", nil
+		}))
+		is.NoErr(err)
+		is.Equal("b/2.txt", first)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		first, err := First("*/*.zip", WithFs(fsys))
+		is.True(errors.Is(err, fs.ErrNotExist))
+		is.Equal(err.Error(), `matching "*/*.zip": file does not exist`)
+		is.Equal("", first)
+	})
+}
+
 func testFs(tb testing.TB, files, dirs []string) fs.FS {
 	tb.Helper()
 
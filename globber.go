@@ -0,0 +1,173 @@
+package fileglob
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Globber runs many globs with the same options, caching the contents of the
+// directories it reads, so globs over the same tree don't read it again.
+//
+// It is safe for concurrent use.
+type Globber struct {
+	opts []OptFunc
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu   sync.RWMutex
+	dirs map[dirKey]cachedDir
+	// generation is bumped by Invalidate, so directories read before it are
+	// not cached after it.
+	generation uint64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats are the statistics of a Globber directory cache.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+type dirKey struct {
+	fs  any
+	dir string
+}
+
+type cachedDir struct {
+	entries []fs.DirEntry
+	read    time.Time
+}
+
+// NewGlobber creates a Globber using the given options for all its globs.
+//
+// Cached directories are read again once they are older than ttl, unless ttl
+// is zero, in which case they are kept until invalidated.
+// Also check Globber.Invalidate.
+func NewGlobber(ttl time.Duration, opts ...OptFunc) *Globber {
+	return &Globber{
+		opts: opts,
+		ttl:  ttl,
+		now:  time.Now,
+		dirs: map[dirKey]cachedDir{},
+	}
+}
+
+// Glob is like the Glob function, using the options the Globber was created
+// with followed by the given ones.
+//
+// Only file systems that are comparable, like the ones from os.DirFS, are
+// cached, as they are used to tell cached directories apart. An Index is
+// not cached either, as it is in memory already.
+func (g *Globber) Glob(pattern string, opts ...OptFunc) ([]string, error) {
+	return Glob(pattern, slices.Concat(g.opts, opts, []OptFunc{g.cache})...)
+}
+
+// Invalidate drops the given directory and all directories inside of it from
+// the cache, for all file systems.
+//
+// The path is given in the same form Glob returns, so Invalidate(".") or
+// Invalidate("/") drop everything.
+func (g *Globber) Invalidate(path string) {
+	path = toNixPath(path)
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	path = strings.TrimPrefix(path, separatorString)
+	if path == "" {
+		path = "."
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.generation++
+	for key := range g.dirs {
+		if path == "." || key.dir == path || strings.HasPrefix(key.dir, path+separatorString) {
+			delete(g.dirs, key)
+		}
+	}
+}
+
+// CacheStats returns how many directory reads were served from the cache, and
+// how many were not.
+func (g *Globber) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:   g.hits.Load(),
+		Misses: g.misses.Load(),
+	}
+}
+
+// cache makes the options file system read directories through the cache.
+func (g *Globber) cache(opts *globOptions) {
+	if _, isIndex := opts.fs.(*Index); isIndex || !reflect.ValueOf(opts.fs).Comparable() {
+		return
+	}
+	cached := cachedFS{FS: opts.fs, globber: g}
+	if lfs, ok := opts.fs.(fs.ReadLinkFS); ok {
+		opts.fs = cachedLinkFS{cachedFS: cached, links: lfs}
+		return
+	}
+	opts.fs = cached
+}
+
+func (g *Globber) readDir(fsys fs.FS, dir string) ([]fs.DirEntry, error) {
+	key := dirKey{fs: fsys, dir: dir}
+
+	g.mu.RLock()
+	cached, ok := g.dirs[key]
+	generation := g.generation
+	g.mu.RUnlock()
+	if ok && (g.ttl == 0 || g.now().Sub(cached.read) < g.ttl) {
+		g.hits.Add(1)
+		return cached.entries, nil
+	}
+
+	g.misses.Add(1)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return entries, err //nolint:wrapcheck
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// the directory may have changed since it was read, if it was invalidated
+	// meanwhile, so it is only cached if it was not
+	if g.generation == generation {
+		g.dirs[key] = cachedDir{entries: entries, read: g.now()}
+	}
+	return entries, nil
+}
+
+// cachedFS is a fs.FS which reads directories through a Globber cache.
+type cachedFS struct {
+	fs.FS
+	globber *Globber
+}
+
+func (f cachedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return f.globber.readDir(f.FS, name)
+}
+
+func (f cachedFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.FS, name) //nolint:wrapcheck
+}
+
+// cachedLinkFS is a cachedFS over a file system which can read symbolic
+// links, which it keeps doing, like for StdlibCompat.
+type cachedLinkFS struct {
+	cachedFS
+	links fs.ReadLinkFS
+}
+
+func (f cachedLinkFS) ReadLink(name string) (string, error) {
+	return f.links.ReadLink(name) //nolint:wrapcheck
+}
+
+func (f cachedLinkFS) Lstat(name string) (fs.FileInfo, error) {
+	return f.links.Lstat(name) //nolint:wrapcheck
+}
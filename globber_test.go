@@ -0,0 +1,197 @@
+package fileglob
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestGlobber(t *testing.T) {
+	t.Parallel()
+
+	newFS := func() *countingFS {
+		return &countingFS{FS: fstest.MapFS{
+			"a/1.txt":   {},
+			"a/b/2.txt": {},
+			"c/3.go":    {},
+		}}
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := newFS()
+		g := NewGlobber(0, WithFs(fsys))
+
+		matches, err := g.Glob("**/*.txt")
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt", "a/b/2.txt"}, matches)
+		is.Equal(int64(4), fsys.readDirs.Load())
+		is.Equal(CacheStats{Hits: 0, Misses: 4}, g.CacheStats())
+
+		matches, err = g.Glob("**/*.go")
+		is.NoErr(err)
+		is.Equal([]string{"c/3.go"}, matches)
+		is.Equal(int64(4), fsys.readDirs.Load())
+		is.Equal(CacheStats{Hits: 4, Misses: 4}, g.CacheStats())
+
+		matches, err = g.Glob("a/*", MatchDirectoryAsFile)
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt", "a/b"}, matches)
+		is.Equal(int64(4), fsys.readDirs.Load())
+		is.Equal(CacheStats{Hits: 6, Misses: 4}, g.CacheStats())
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := newFS()
+		now := time.Now()
+		g := NewGlobber(time.Minute, WithFs(fsys))
+		g.now = func() time.Time { return now }
+
+		_, err := g.Glob("a/*")
+		is.NoErr(err)
+		is.Equal(int64(2), fsys.readDirs.Load())
+
+		now = now.Add(30 * time.Second)
+		_, err = g.Glob("a/*")
+		is.NoErr(err)
+		is.Equal(int64(2), fsys.readDirs.Load())
+
+		now = now.Add(time.Minute)
+		_, err = g.Glob("a/*")
+		is.NoErr(err)
+		is.Equal(int64(4), fsys.readDirs.Load())
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := newFS()
+		g := NewGlobber(0, WithFs(fsys))
+
+		_, err := g.Glob("**")
+		is.NoErr(err)
+		is.Equal(int64(4), fsys.readDirs.Load())
+
+		g.Invalidate("./a")
+		_, err = g.Glob("**")
+		is.NoErr(err)
+		is.Equal(int64(6), fsys.readDirs.Load())
+
+		g.Invalidate("c")
+		_, err = g.Glob("**")
+		is.NoErr(err)
+		is.Equal(int64(7), fsys.readDirs.Load())
+
+		g.Invalidate(".")
+		_, err = g.Glob("**")
+		is.NoErr(err)
+		is.Equal(int64(11), fsys.readDirs.Load())
+	})
+
+	t.Run("invalidate while reading", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var g *Globber
+		fsys := &hookFS{FS: newFS(), hook: func(string) { g.Invalidate(".") }}
+		g = NewGlobber(0, WithFs(fsys))
+
+		_, err := g.Glob("a/*")
+		is.NoErr(err)
+		is.Equal(int64(2), fsys.FS.(*countingFS).readDirs.Load())
+
+		fsys.hook = nil
+		_, err = g.Glob("a/*")
+		is.NoErr(err)
+		is.Equal(int64(4), fsys.FS.(*countingFS).readDirs.Load()) // should not have cached the stale reads
+	})
+
+	t.Run("index", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		idx, err := BuildIndex(newFS())
+		is.NoErr(err)
+		g := NewGlobber(0, WithFs(idx))
+		matches, err := g.Glob("**/*.txt")
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt", "a/b/2.txt"}, matches)
+		is.Equal(CacheStats{}, g.CacheStats()) // should use the index as is
+	})
+
+	t.Run("symbolic links", func(t *testing.T) {
+		t.Parallel()
+		if isWindows() {
+			t.Skip("symbolic links need privileges on windows")
+		}
+		is := is.New(t)
+		dir := t.TempDir()
+		is.NoErr(os.Symlink("nope", filepath.Join(dir, "broken")))
+		fsys := os.DirFS(dir)
+
+		expected, err := Glob("broken", WithFs(fsys), StdlibCompat)
+		is.NoErr(err)
+		is.Equal([]string{"broken"}, expected)
+		g := NewGlobber(0, WithFs(fsys), StdlibCompat)
+		matches, err := g.Glob("broken")
+		is.NoErr(err)
+		is.Equal(expected, matches)
+	})
+
+	t.Run("not comparable", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		g := NewGlobber(0, WithFs(fstest.MapFS{
+			"a/1.txt": {},
+		}))
+		for range 2 {
+			matches, err := g.Glob("**/*.txt")
+			is.NoErr(err)
+			is.Equal([]string{"a/1.txt"}, matches)
+		}
+		is.Equal(CacheStats{}, g.CacheStats())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		fsys := newFS()
+		g := NewGlobber(0, WithFs(fsys))
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				is := is.NewRelaxed(t)
+				matches, err := g.Glob("**/*.txt", WithConcurrency(2))
+				is.NoErr(err)
+				is.Equal([]string{"a/1.txt", "a/b/2.txt"}, matches)
+				g.Invalidate("a/b")
+			})
+		}
+		wg.Wait()
+
+		stats := g.CacheStats()
+		is.Equal(int64(40), stats.Hits+stats.Misses)
+	})
+}
+
+// hookFS calls hook after reading each directory.
+type hookFS struct {
+	fs.FS
+	hook func(name string)
+}
+
+func (f *hookFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.FS, name)
+	if f.hook != nil {
+		f.hook(name)
+	}
+	return entries, err
+}
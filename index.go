@@ -0,0 +1,422 @@
+package fileglob
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// indexVersion is the version of the format written by Index.WriteTo.
+const indexVersion = 1
+
+// Index is a snapshot of a file tree: paths, types, sizes and modification
+// times, but no contents. It answers globs with the same semantics as Glob,
+// without touching the file system it was built from.
+//
+// Symbolic links are recorded along with their targets, when the file system
+// can read them, and followed like the file system would. Links to absolute
+// paths or outside of the indexed tree are taken as broken, as there is
+// nothing known about what they point to.
+//
+// An Index is a fs.FS itself, whose files can be listed and stat'ed, but not
+// read. It is safe for concurrent use, except for Refresh.
+type Index struct {
+	dirs map[string]*indexDir
+}
+
+type indexDir struct {
+	Mode    fs.FileMode
+	ModTime time.Time
+	Entries []indexEntry // sorted by name
+}
+
+type indexEntry struct {
+	Name    string
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+	Target  string // of symbolic links
+}
+
+// indexData is what is written to disk.
+type indexData struct {
+	Version int
+	Dirs    map[string]*indexDir
+}
+
+var _ interface {
+	fs.ReadDirFS
+	fs.StatFS
+	fs.ReadLinkFS
+} = &Index{}
+
+// maxLinks is how many symbolic links are followed while resolving a path
+// before taking it as a loop, like Linux does.
+const maxLinks = 40
+
+var errLinkLoop = errors.New("too many levels of symbolic links")
+
+// BuildIndex snapshots the whole tree of the given file system.
+func BuildIndex(fsys fs.FS) (*Index, error) {
+	info, err := fs.Stat(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("build index: %w", err)
+	}
+	idx := &Index{dirs: map[string]*indexDir{}}
+	if err := idx.scan(fsys, ".", info); err != nil {
+		return nil, fmt.Errorf("build index: %w", err)
+	}
+	return idx, nil
+}
+
+// ReadIndex reads an index written by Index.WriteTo.
+func ReadIndex(r io.Reader) (*Index, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	defer zr.Close()
+
+	var data indexData
+	if err := gob.NewDecoder(zr).Decode(&data); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	if data.Version != indexVersion {
+		return nil, fmt.Errorf("read index: unsupported version %d", data.Version)
+	}
+	if data.Dirs == nil || data.Dirs["."] == nil {
+		return nil, errors.New("read index: missing root directory")
+	}
+	return &Index{dirs: data.Dirs}, nil
+}
+
+// WriteTo writes the index to w in a compact binary format, which can be read
+// back with ReadIndex. It fails for an Index that was never built.
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	if idx.dirs["."] == nil {
+		return 0, errors.New("write index: missing root directory")
+	}
+	cw := &countingWriter{w: w}
+	zw := gzip.NewWriter(cw)
+	if err := gob.NewEncoder(zw).Encode(indexData{
+		Version: indexVersion,
+		Dirs:    idx.dirs,
+	}); err != nil {
+		return cw.n, fmt.Errorf("write index: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return cw.n, fmt.Errorf("write index: %w", err)
+	}
+	return cw.n, nil
+}
+
+// Glob is like the Glob function, but answers from the index.
+//
+// The index takes the place of the file system, so WithFs and the file
+// system picked by MaybeRootFS have no effect. MaybeRootFS still strips the
+// root from absolute patterns, for indexes built from the root directory.
+func (idx *Index) Glob(pattern string, opts ...OptFunc) ([]string, error) {
+	return Glob(pattern, append(slices.Clip(opts), WithFs(idx))...)
+}
+
+// Refresh updates the index from the given file system, which should be the
+// one the index was built from. Refreshing an empty Index builds it.
+//
+// Only directories whose modification time changed are read again, along with
+// new directories. As a directory modification time only changes when entries
+// are added, removed or renamed, changes in the size or modification time of
+// files inside unchanged directories are not picked up.
+func (idx *Index) Refresh(fsys fs.FS) error {
+	return idx.refresh(fsys, ".")
+}
+
+func (idx *Index) refresh(fsys fs.FS, dir string) error {
+	info, err := fs.Stat(fsys, dir)
+	if dir != "." && (errors.Is(err, fs.ErrNotExist) || err == nil && !info.IsDir()) {
+		idx.drop(dir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("refresh index: %w", err)
+	}
+
+	cached, ok := idx.dirs[dir]
+	if !ok {
+		if err := idx.scan(fsys, dir, info); err != nil {
+			return fmt.Errorf("refresh index: %w", err)
+		}
+		return nil
+	}
+
+	if !info.ModTime().Equal(cached.ModTime) {
+		if err := idx.read(fsys, dir, info); err != nil {
+			return fmt.Errorf("refresh index: %w", err)
+		}
+		for _, entry := range cached.Entries {
+			if !entry.Mode.IsDir() {
+				continue
+			}
+			child := path.Join(dir, entry.Name)
+			if info, err := idx.Stat(child); err != nil || !info.IsDir() {
+				idx.drop(child)
+			}
+		}
+		cached = idx.dirs[dir]
+	}
+
+	for _, entry := range cached.Entries {
+		if !entry.Mode.IsDir() {
+			continue
+		}
+		if err := idx.refresh(fsys, path.Join(dir, entry.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan reads the given directory and all directories inside of it.
+func (idx *Index) scan(fsys fs.FS, dir string, info fs.FileInfo) error {
+	if err := idx.read(fsys, dir, info); err != nil {
+		return err
+	}
+	for _, entry := range idx.dirs[dir].Entries {
+		if !entry.Mode.IsDir() {
+			continue
+		}
+		if err := idx.scan(fsys, path.Join(dir, entry.Name), indexInfo{&entry}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read reads the entries of a single directory.
+func (idx *Index) read(fsys fs.FS, dir string, info fs.FileInfo) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	cached := &indexDir{
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		Entries: make([]indexEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		var target string
+		if lfs, ok := fsys.(fs.ReadLinkFS); ok && info.Mode()&fs.ModeSymlink != 0 {
+			if target, err = lfs.ReadLink(path.Join(dir, entry.Name())); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+		cached.Entries = append(cached.Entries, indexEntry{
+			Name:    entry.Name(),
+			Mode:    info.Mode(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Target:  target,
+		})
+	}
+	slices.SortFunc(cached.Entries, func(a, b indexEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	if idx.dirs == nil {
+		idx.dirs = map[string]*indexDir{}
+	}
+	idx.dirs[dir] = cached
+	return nil
+}
+
+// drop removes the given directory and everything inside of it.
+func (idx *Index) drop(dir string) {
+	for name := range idx.dirs {
+		if name == dir || dir == "." || strings.HasPrefix(name, dir+separatorString) {
+			delete(idx.dirs, name)
+		}
+	}
+}
+
+// Open opens the named file or directory. Files can not be read.
+func (idx *Index) Open(name string) (fs.File, error) {
+	info, err := idx.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &indexFile{idx: idx, name: name, info: info}, nil
+}
+
+// Stat returns the information about the named file or directory, following
+// symbolic links.
+func (idx *Index) Stat(name string) (fs.FileInfo, error) {
+	_, entry, err := idx.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	renamed := *entry
+	renamed.Name = path.Base(name)
+	return indexInfo{&renamed}, nil
+}
+
+// Lstat returns the information about the named file or directory, without
+// following it if it is a symbolic link.
+func (idx *Index) Lstat(name string) (fs.FileInfo, error) {
+	_, entry, err := idx.resolve("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return indexInfo{entry}, nil
+}
+
+// ReadLink returns the target of the named symbolic link.
+func (idx *Index) ReadLink(name string) (string, error) {
+	_, entry, err := idx.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if entry.Mode&fs.ModeSymlink == 0 || entry.Target == "" {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return entry.Target, nil
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name.
+func (idx *Index) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, _, err := idx.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	dir, ok := idx.dirs[resolved]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]fs.DirEntry, len(dir.Entries))
+	for i := range dir.Entries {
+		entries[i] = indexInfo{&dir.Entries[i]}
+	}
+	return entries, nil
+}
+
+// resolve follows the symbolic links in the named path, returning the path
+// and the entry it leads to. The last element is only followed if follow is
+// set, like for Stat but not Lstat.
+func (idx *Index) resolve(op, name string, follow bool) (string, *indexEntry, error) {
+	if !fs.ValidPath(name) {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	notExist := &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	root, ok := idx.dirs["."]
+	if !ok {
+		return "", nil, notExist
+	}
+
+	dir, rest, links := ".", name, 0
+	entry := &indexEntry{Name: ".", Mode: root.Mode, ModTime: root.ModTime}
+	for rest != "." {
+		elem, after, _ := strings.Cut(rest, "/")
+		parent, ok := idx.dirs[dir]
+		if !ok {
+			return "", nil, notExist
+		}
+		i, found := slices.BinarySearchFunc(parent.Entries, elem, compareEntryName)
+		if !found {
+			return "", nil, notExist
+		}
+		entry = &parent.Entries[i]
+		if entry.Mode&fs.ModeSymlink == 0 || after == "" && !follow {
+			dir, rest = path.Join(dir, elem), after
+			if rest == "" {
+				break
+			}
+			continue
+		}
+
+		links++
+		if links > maxLinks {
+			return "", nil, &fs.PathError{Op: op, Path: name, Err: errLinkLoop}
+		}
+		target := path.Join(dir, entry.Target)
+		if entry.Target == "" || path.IsAbs(entry.Target) || target == ".." || strings.HasPrefix(target, "../") {
+			return "", nil, notExist
+		}
+		dir, rest = ".", path.Join(target, after)
+		entry = &indexEntry{Name: ".", Mode: root.Mode, ModTime: root.ModTime}
+	}
+	return dir, entry, nil
+}
+
+func compareEntryName(entry indexEntry, name string) int {
+	return strings.Compare(entry.Name, name)
+}
+
+// indexInfo is both the fs.FileInfo and the fs.DirEntry of an indexed entry.
+type indexInfo struct {
+	entry *indexEntry
+}
+
+func (i indexInfo) Name() string               { return i.entry.Name }
+func (i indexInfo) Size() int64                { return i.entry.Size }
+func (i indexInfo) Mode() fs.FileMode          { return i.entry.Mode }
+func (i indexInfo) ModTime() time.Time         { return i.entry.ModTime }
+func (i indexInfo) IsDir() bool                { return i.entry.Mode.IsDir() }
+func (i indexInfo) Sys() any                   { return nil }
+func (i indexInfo) Type() fs.FileMode          { return i.entry.Mode.Type() }
+func (i indexInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// indexFile is an opened file or directory of an Index.
+type indexFile struct {
+	idx    *Index
+	name   string
+	info   fs.FileInfo
+	offset int
+}
+
+func (f *indexFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *indexFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.ErrUnsupported}
+}
+
+func (f *indexFile) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *indexFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := f.idx.ReadDir(f.name)
+	if err != nil {
+		return nil, err
+	}
+	entries = entries[f.offset:]
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	f.offset += len(entries)
+	return entries, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err //nolint:wrapcheck
+}
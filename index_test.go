@@ -0,0 +1,263 @@
+package fileglob
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/testfs"
+	"github.com/matryer/is"
+)
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	files := []string{
+		"a/1.txt",
+		"a/b/2.txt",
+		"a/b/c/3.go",
+		"d/4.go",
+		"e.txt",
+	}
+
+	testCases := []struct {
+		pattern string
+		opts    []OptFunc
+	}{
+		{"**/*.txt", nil},
+		{"*/*", nil},
+		{"a", nil},
+		{"a/b", []OptFunc{MatchDirectoryAsFile}},
+		{"{a,d}/**", []OptFunc{MatchDirectoryAsFile}},
+		{"e.txt", nil},
+		{"**", []OptFunc{WithFilters(SizeAtLeast(9))}},
+		{"**/*.go", []OptFunc{WithSort(SortBreadthFirst)}},
+	}
+
+	fsys := testFs(t, files, []string{"f"})
+	idx, err := BuildIndex(fsys)
+	is.New(t).NoErr(err)
+
+	var buf bytes.Buffer
+	_, err = idx.WriteTo(&buf)
+	is.New(t).NoErr(err)
+	read, err := ReadIndex(&buf)
+	is.New(t).NoErr(err)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expected, err := Glob(testCase.pattern, append([]OptFunc{WithFs(fsys)}, testCase.opts...)...)
+			is.NoErr(err)
+			is.True(len(expected) > 0) // expected matches
+
+			matches, err := idx.Glob(testCase.pattern, testCase.opts...)
+			is.NoErr(err)
+			is.Equal(expected, matches)
+
+			matches, err = read.Glob(testCase.pattern, testCase.opts...)
+			is.NoErr(err)
+			is.Equal(expected, matches)
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := idx.Glob("a/nope")
+		is.True(errors.Is(err, fs.ErrNotExist))
+		is.Equal([]string{}, matches)
+	})
+
+	t.Run("files can not be read", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := fs.ReadFile(idx, "e.txt")
+		is.True(errors.Is(err, errors.ErrUnsupported))
+	})
+}
+
+func TestIndexDoesNotTouchDisk(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	fsys := testFs(t, []string{"a/1.txt"}, nil)
+	idx, err := BuildIndex(fsys)
+	is.NoErr(err)
+	is.NoErr(os.RemoveAll(filepath.Join(fsys.(testfs.FS).Path(), "a")))
+
+	matches, err := idx.Glob("a/*")
+	is.NoErr(err)
+	is.Equal([]string{"a/1.txt"}, matches)
+}
+
+func TestIndexSymlinks(t *testing.T) {
+	t.Parallel()
+	if isWindows() {
+		t.Skip("symbolic links need privileges on windows")
+	}
+
+	root := t.TempDir()
+	is.New(t).NoErr(os.MkdirAll(filepath.Join(root, "real", "sub"), 0o755))
+	is.New(t).NoErr(os.WriteFile(filepath.Join(root, "real", "f"), nil, 0o644))
+	for name, target := range map[string]string{
+		"link":         "real",
+		"real/sub/up":  "..",
+		"real/sub/abs": root,
+		"broken":       "nope",
+		"loop":         "loop",
+		"outside":      "../x",
+	} {
+		is.New(t).NoErr(os.Symlink(target, filepath.Join(root, name)))
+	}
+	fsys := os.DirFS(root)
+	idx, err := BuildIndex(fsys)
+	is.New(t).NoErr(err)
+
+	for _, pattern := range []string{
+		"link",
+		"link/*",
+		"link/f",
+		"*/f",
+		"**/*",
+		"link/sub/up/f",
+		"real/sub/up/*",
+		"broken",
+		"broken/*",
+		"outside",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expected, expectedErr := Glob(pattern, WithFs(fsys))
+			matches, err := idx.Glob(pattern)
+			is.Equal(expectedErr, err)
+			is.Equal(expected, matches)
+		})
+	}
+
+	t.Run("stat", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		info, err := idx.Stat("link")
+		is.NoErr(err)
+		is.True(info.IsDir())
+		is.Equal("link", info.Name())
+
+		info, err = idx.Lstat("link")
+		is.NoErr(err)
+		is.Equal(fs.ModeSymlink, info.Mode().Type())
+		target, err := idx.ReadLink("link")
+		is.NoErr(err)
+		is.Equal("real", target)
+		_, err = idx.ReadLink("real")
+		is.True(errors.Is(err, fs.ErrInvalid))
+
+		_, err = idx.Stat("loop")
+		is.True(errors.Is(err, errLinkLoop))
+		// the index knows nothing about what is outside of it
+		_, err = idx.Stat("real/sub/abs")
+		is.True(errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestIndexRefresh(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	fsys := testFs(t, []string{
+		"a/1.txt",
+		"a/b/2.txt",
+		"c/3.txt",
+		"d/e/4.txt",
+	}, nil)
+	root := fsys.(testfs.FS).Path()
+	counting := &countingFS{FS: fsys}
+
+	idx, err := BuildIndex(counting)
+	is.NoErr(err)
+	is.Equal(int64(6), counting.readDirs.Load())
+
+	is.NoErr(idx.Refresh(counting))
+	is.Equal(int64(6), counting.readDirs.Load()) // nothing changed
+
+	is.NoErr(os.WriteFile(filepath.Join(root, "a", "b", "5.txt"), nil, 0o644))
+	is.NoErr(os.MkdirAll(filepath.Join(root, "c", "f"), 0o755))
+	is.NoErr(os.WriteFile(filepath.Join(root, "c", "f", "6.txt"), nil, 0o644))
+	is.NoErr(os.RemoveAll(filepath.Join(root, "d", "e")))
+	// make sure modification times change even on coarse file systems
+	later := time.Now().Add(time.Hour)
+	for _, dir := range []string{"a/b", "c", "d"} {
+		is.NoErr(os.Chtimes(filepath.Join(root, dir), later, later))
+	}
+
+	is.NoErr(idx.Refresh(counting))
+	is.Equal(int64(10), counting.readDirs.Load()) // a/b, c, c/f and d
+
+	matches, err := idx.Glob("**/*.txt")
+	is.NoErr(err)
+	is.Equal([]string{
+		"a/1.txt",
+		"a/b/2.txt",
+		"a/b/5.txt",
+		"c/3.txt",
+		"c/f/6.txt",
+	}, matches)
+
+	_, err = idx.Stat("d/e")
+	is.True(errors.Is(err, fs.ErrNotExist))
+	_, err = idx.ReadDir("d/e")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func TestReadIndex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not an index", func(t *testing.T) {
+		t.Parallel()
+		_, err := ReadIndex(bytes.NewBufferString("nope"))
+		is.New(t).True(err != nil) // expected an error
+	})
+
+	t.Run("empty index", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var buf bytes.Buffer
+		n, err := (&Index{}).WriteTo(&buf)
+		is.True(err != nil) // expected an error
+		is.Equal(err.Error(), "write index: missing root directory")
+		is.Equal(int64(0), n)
+		_, err = ReadIndex(&buf)
+		is.True(err != nil) // expected an error
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var idx Index
+		_, err := idx.Stat(".")
+		is.True(errors.Is(err, fs.ErrNotExist))
+		matches, err := idx.Glob("*") // should not panic
+		is.NoErr(err)
+		is.Equal([]string{}, matches)
+
+		is.NoErr(idx.Refresh(testFs(t, []string{"a/1.txt"}, nil)))
+		matches, err = idx.Glob("**/*.txt")
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt"}, matches)
+
+		var buf bytes.Buffer
+		_, err = idx.WriteTo(&buf)
+		is.NoErr(err)
+		read, err := ReadIndex(&buf)
+		is.NoErr(err)
+		matches, err = read.Glob("**/*.txt")
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt"}, matches)
+	})
+}
@@ -0,0 +1,201 @@
+package fileglob
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// Matcher matches paths in a pattern language of its own, for Glob to walk
+// what it matches.
+type Matcher interface {
+	// Match reports whether the path, relative to the root of the file
+	// system, matches.
+	Match(path string) bool
+	// StaticPrefix returns the path walking starts from, which all matches
+	// are inside of, or "." to walk everything. When the pattern matches a
+	// single path, it is that path.
+	StaticPrefix() string
+}
+
+// MatcherFunc compiles a pattern, relative to the root of the file system,
+// into a Matcher.
+type MatcherFunc func(pattern string) (Matcher, error)
+
+// WithMatcher compiles patterns with the given function, instead of as glob
+// patterns. The walking, the filters and the directory modes of Glob still
+// apply, as does the file system prefix handling, like MaybeRootFS.
+//
+// Glob syntax extensions, like bash sequence expressions, POSIX character
+// classes, ExtendedGlob or StrictDoublestar, are not applied to the pattern.
+// A single path missing is reported as an error only when the static prefix
+// of the matcher matches itself.
+func WithMatcher(compile MatcherFunc) OptFunc {
+	return func(opts *globOptions) {
+		opts.matcher = compile
+	}
+}
+
+// globMatcher is a Matcher for glob patterns.
+type globMatcher struct {
+	glob.Glob
+	prefix string
+}
+
+func (m globMatcher) StaticPrefix() string { return m.prefix }
+
+// GobwasMatcher compiles glob patterns with gobwas/glob alone, which is what
+// Glob does by default, without its syntax extensions and its workarounds for
+// patterns gobwas mismatches.
+func GobwasMatcher(pattern string) (Matcher, error) {
+	g, err := glob.Compile(pattern, separatorRune)
+	if err != nil {
+		return nil, fmt.Errorf("compile glob pattern: %w", err)
+	}
+	prefix, err := staticPrefix(pattern, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine static prefix: %w", err)
+	}
+	return globMatcher{Glob: g, prefix: prefix}, nil
+}
+
+// regexpMatcher is a Matcher for regular expressions.
+type regexpMatcher struct {
+	re     *regexp.Regexp
+	prefix string
+}
+
+func (m regexpMatcher) Match(path string) bool { return m.re.MatchString(path) }
+
+func (m regexpMatcher) StaticPrefix() string { return m.prefix }
+
+// RegexpMatcher compiles patterns as regular expressions, with the syntax of
+// the regexp package, which have to match whole paths, like "src/.*\.go".
+func RegexpMatcher(pattern string) (Matcher, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("compile regular expression: %w", err)
+	}
+	// LiteralPrefix is always empty for anchored expressions, while every
+	// match of the unanchored one starts with the same literal
+	unanchored, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile regular expression: %w", err)
+	}
+	literal, complete := unanchored.LiteralPrefix()
+	return regexpMatcher{re: re, prefix: literalPrefix(literal, complete)}, nil
+}
+
+// pathMatcher is a Matcher for the patterns of path.Match.
+type pathMatcher struct {
+	pattern string
+	prefix  string
+}
+
+func (m pathMatcher) Match(name string) bool {
+	ok, _ := path.Match(m.pattern, name)
+	return ok
+}
+
+func (m pathMatcher) StaticPrefix() string { return m.prefix }
+
+// PathMatcher compiles patterns with the syntax of path.Match, which has no
+// "**" or alternatives, like "src/*/*.go".
+func PathMatcher(pattern string) (Matcher, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("compile pattern: %w", err)
+	}
+
+	var static []string
+	for part := range strings.SplitSeq(pattern, separatorString) {
+		text, ok := pathPartText(part)
+		if !ok {
+			break
+		}
+		static = append(static, text)
+	}
+	return pathMatcher{pattern: pattern, prefix: literalPrefix(strings.Join(static, separatorString), true)}, nil
+}
+
+// pathPartText returns the unescaped text of a path element of a path.Match
+// pattern, unless it contains matchers.
+func pathPartText(part string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(part); i++ {
+		switch part[i] {
+		case '*', '?', '[':
+			return "", false
+		case '\\':
+			if i+1 < len(part) {
+				i++
+			}
+		}
+		sb.WriteByte(part[i])
+	}
+	return sb.String(), true
+}
+
+// literalPrefix returns the static prefix for the literal text every match
+// starts with, which is a whole path if complete and otherwise ends with a
+// path element that is not complete.
+func literalPrefix(literal string, complete bool) string {
+	if !complete {
+		i := strings.LastIndex(literal, separatorString)
+		if i < 0 {
+			return "."
+		}
+		literal = literal[:i]
+	}
+	if literal == "" {
+		return "."
+	}
+	return path.Clean(literal)
+}
+
+// newMatcher returns the Matcher for the pattern, which is relative to the
+// root of the file system.
+func (opts *globOptions) newMatcher(pattern string) (Matcher, error) {
+	if opts.matcher != nil {
+		return opts.matcher(pattern)
+	}
+
+	g, err := opts.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := staticPrefix(pattern, opts.extendedGlob)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine static prefix: %w", err)
+	}
+	return globMatcher{Glob: g, prefix: prefix}, nil
+}
+
+// walkedPrefixes returns the static prefixes Glob walks for the pattern.
+// Those of glob patterns allow to walk less than the one of the matcher.
+func (opts *globOptions) walkedPrefixes(pattern string, m Matcher) ([]string, error) {
+	if opts.matcher != nil {
+		return []string{m.StaticPrefix()}, nil
+	}
+	prefixes, err := staticPrefixes(pattern, opts.extendedGlob)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine static prefix: %w", err)
+	}
+	return prefixes, nil
+}
+
+// isSinglePath reports whether the pattern matches only the given static
+// prefix, so it not existing is an error. static is whether the pattern
+// contains no matchers, which only matters for glob patterns.
+func (opts *globOptions) isSinglePath(static bool, prefix string, m Matcher) bool {
+	if opts.stdlibCompat {
+		// like filepath.Glob, which ignores missing files
+		return false
+	}
+	if opts.matcher != nil {
+		return m.Match(prefix)
+	}
+	return static
+}
@@ -0,0 +1,135 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+// suffixMatcher is a Matcher for a language of our own, where patterns are
+// the suffix of the paths they match.
+type suffixMatcher string
+
+func (m suffixMatcher) Match(path string) bool { return strings.HasSuffix(path, string(m)) }
+
+func (suffixMatcher) StaticPrefix() string { return "." }
+
+func TestWithMatcher(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"src/a.go":      {},
+		"src/b_test.go": {},
+		"pkg/c.go":      {},
+		"README.md":     {},
+		"{1..2}":        {},
+	}
+	suffix := func(pattern string) (Matcher, error) {
+		return suffixMatcher(pattern), nil
+	}
+
+	testCases := []struct {
+		name     string
+		pattern  string
+		compile  MatcherFunc
+		expected []string
+	}{
+		{"regexp", `src/.*\.go`, RegexpMatcher, []string{"src/a.go", "src/b_test.go"}},
+		{"regexp alternatives", `(src|pkg)/[a-c]\.go`, RegexpMatcher, []string{"pkg/c.go", "src/a.go"}},
+		{"regexp across directories", `.*_test\.go`, RegexpMatcher, []string{"src/b_test.go"}},
+		{"regexp directory", `src`, RegexpMatcher, []string{"src/a.go", "src/b_test.go"}},
+		{"regexp nothing", `src/nope.*`, RegexpMatcher, nil},
+		{"path", "src/*.go", PathMatcher, []string{"src/a.go", "src/b_test.go"}},
+		{"path class", "*/[ac].go", PathMatcher, []string{"pkg/c.go", "src/a.go"}},
+		{"path braces", "{1..2}", PathMatcher, []string{"{1..2}"}},
+		{"path no super star", "**.go", PathMatcher, nil},
+		{"gobwas", "**/*.go", GobwasMatcher, []string{"pkg/c.go", "src/a.go", "src/b_test.go"}},
+		{"own", ".md", suffix, []string{"README.md"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			opts := []OptFunc{WithFs(fsys), WithMatcher(testCase.compile)}
+			matches, err := Glob(testCase.pattern, opts...)
+			is.NoErr(err)
+			is.Equal(testCase.expected, matches)
+
+			for _, path := range []string{"src/a.go", "src/b_test.go", "pkg/c.go", "README.md", "{1..2}"} {
+				e, err := Explain(testCase.pattern, path, opts...)
+				is.NoErr(err)
+				is.Equal(slices.Contains(matches, path), e.Matched) // should agree with Glob
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob(`src/nope\.go`, WithFs(fsys), WithMatcher(RegexpMatcher))
+		is.True(errors.Is(err, fs.ErrNotExist)) // should report the missing file
+		_, err = Glob(`src/nope.go`, WithFs(fsys), WithMatcher(PathMatcher))
+		is.True(errors.Is(err, fs.ErrNotExist)) // should report the missing file
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob(`src/(`, WithFs(fsys), WithMatcher(RegexpMatcher))
+		is.True(err != nil)                                               // expected an error
+		is.True(ValidPattern(`src/(`, WithMatcher(RegexpMatcher)) != nil) // expected an error
+		is.True(ValidPattern(`src/[`, WithMatcher(PathMatcher)) != nil)   // expected an error
+		is.NoErr(ValidPattern(`src/(a|b)`, WithMatcher(RegexpMatcher)))
+	})
+
+	t.Run("resolve options", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions(`src/.*\.go`, WithFs(fsys), WithMatcher(RegexpMatcher))
+		is.NoErr(err)
+		is.Equal("src", options.StaticPrefix)
+		is.Equal([]string{"src"}, options.StaticPrefixes)
+		is.Equal(`src/.*\.go`, options.Pattern)
+	})
+}
+
+func TestMatcherStaticPrefix(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		compile  MatcherFunc
+		expected string
+	}{
+		{`src/.*\.go`, RegexpMatcher, "src"},
+		{`src/a.*`, RegexpMatcher, "src"},
+		{`src/a\.go`, RegexpMatcher, "src/a.go"},
+		{`src/a.go`, RegexpMatcher, "src"},
+		{`a/b/c.*`, RegexpMatcher, "a/b"},
+		{`(?i)src/.*`, RegexpMatcher, "."},
+		{`.*`, RegexpMatcher, "."},
+		{`src|pkg`, RegexpMatcher, "."},
+		{"src/*.go", PathMatcher, "src"},
+		{"a/b/c.go", PathMatcher, "a/b/c.go"},
+		{`a/\*b/*`, PathMatcher, "a/*b"},
+		{"*", PathMatcher, "."},
+		{"a/**/b", GobwasMatcher, "a"},
+		{"{a,b}/c", GobwasMatcher, "."},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			m, err := testCase.compile(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.expected, m.StaticPrefix())
+		})
+	}
+}
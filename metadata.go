@@ -0,0 +1,242 @@
+package fileglob
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterType is the kind of check a Filter does.
+type FilterType string
+
+// Filter types supported by Filter.
+const (
+	FilterSizeAtLeast    FilterType = "size_at_least"
+	FilterSizeAtMost     FilterType = "size_at_most"
+	FilterModifiedAfter  FilterType = "modified_after"
+	FilterModifiedBefore FilterType = "modified_before"
+	FilterExecutable     FilterType = "executable"
+	FilterModeMatches    FilterType = "mode_matches"
+	FilterOwnedBy        FilterType = "owned_by"
+	FilterNotOwnedBy     FilterType = "not_owned_by"
+	FilterGroupOwnedBy   FilterType = "group_owned_by"
+)
+
+// Filter is a declarative filter on the metadata of a match, similar to the
+// tests supported by find(1).
+//
+// Unlike a FilterFunc, it can be serialized, so it can be stored in a
+// configuration file next to the pattern it applies to.
+// Use WithFilters to add them to Glob.
+type Filter struct {
+	Type FilterType `json:"type" yaml:"type"`
+	Size int64      `json:"size,omitempty" yaml:"size,omitempty"`
+	Time time.Time  `json:"time,omitzero" yaml:"time,omitempty"`
+	Mode FilterMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	ID   *uint32    `json:"id,omitempty" yaml:"id,omitempty"`
+}
+
+// SizeAtLeast keeps files whose size is at least the given amount of bytes.
+func SizeAtLeast(size int64) Filter {
+	return Filter{Type: FilterSizeAtLeast, Size: size}
+}
+
+// SizeAtMost keeps files whose size is at most the given amount of bytes.
+func SizeAtMost(size int64) Filter {
+	return Filter{Type: FilterSizeAtMost, Size: size}
+}
+
+// ModifiedAfter keeps files modified strictly after the given time.
+func ModifiedAfter(t time.Time) Filter {
+	return Filter{Type: FilterModifiedAfter, Time: t}
+}
+
+// ModifiedBefore keeps files modified strictly before the given time.
+func ModifiedBefore(t time.Time) Filter {
+	return Filter{Type: FilterModifiedBefore, Time: t}
+}
+
+// Executable keeps regular files with at least one executable bit set.
+func Executable() Filter {
+	return Filter{Type: FilterExecutable}
+}
+
+// ModeMatches keeps files whose mode has all the bits of the given mask set.
+//
+// For example, ModeMatches(0o600) keeps files readable and writable by their
+// owner, and ModeMatches(fs.ModeSymlink) keeps symbolic links.
+func ModeMatches(mask fs.FileMode) Filter {
+	return Filter{Type: FilterModeMatches, Mode: FilterMode(mask)}
+}
+
+// FilterMode is the mode mask of a ModeMatches filter.
+//
+// It is serialized as text, in the form fs.FileMode.String returns, like
+// "-rw-r--r--" or "d---------". Besides that form, it can be read from an
+// octal string, like "0644", or a number.
+type FilterMode fs.FileMode
+
+// modeTypes are the letters fs.FileMode.String uses for the type bits, from
+// the highest bit down.
+const modeTypes = "dalTLDpSugct?"
+
+// String returns the mode like fs.FileMode.String does.
+func (m FilterMode) String() string {
+	return fs.FileMode(m).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m FilterMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *FilterMode) UnmarshalText(text []byte) error {
+	s := string(text)
+	if n, err := strconv.ParseUint(s, 0, 32); err == nil {
+		*m = FilterMode(n)
+		return nil
+	}
+
+	const perms = "rwxrwxrwx"
+	if len(s) < 1+len(perms) {
+		return fmt.Errorf("invalid mode: %q", s)
+	}
+	types, bits := s[:len(s)-len(perms)], s[len(s)-len(perms):]
+	var mode fs.FileMode
+	if types != "-" {
+		for _, c := range types {
+			i := strings.IndexRune(modeTypes, c)
+			if i < 0 {
+				return fmt.Errorf("invalid mode: %q", s)
+			}
+			mode |= 1 << (31 - i)
+		}
+	}
+	for i := range len(perms) {
+		switch bits[i] {
+		case perms[i]:
+			mode |= 1 << (8 - i)
+		case '-':
+		default:
+			return fmt.Errorf("invalid mode: %q", s)
+		}
+	}
+	*m = FilterMode(mode)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, to read numbers as well as the
+// text forms.
+func (m *FilterMode) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(`"`)) {
+		return m.UnmarshalText(data)
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid mode: %w", err)
+	}
+	return m.UnmarshalText([]byte(s))
+}
+
+// OwnedBy keeps files owned by the given user id.
+//
+// Ownership is only known on file systems whose fs.FileInfo.Sys() returns a
+// *syscall.Stat_t, like os.DirFS on Unix. On other file systems, like
+// fstest.MapFS, or on Windows, the ownership filters keep all files.
+func OwnedBy(uid uint32) Filter {
+	return Filter{Type: FilterOwnedBy, ID: &uid}
+}
+
+// NotOwnedBy keeps files not owned by the given user id, for example
+// NotOwnedBy(0) to find files not owned by root.
+//
+// Also check OwnedBy.
+func NotOwnedBy(uid uint32) Filter {
+	return Filter{Type: FilterNotOwnedBy, ID: &uid}
+}
+
+// GroupOwnedBy keeps files owned by the given group id.
+//
+// Also check OwnedBy.
+func GroupOwnedBy(gid uint32) Filter {
+	return Filter{Type: FilterGroupOwnedBy, ID: &gid}
+}
+
+// WithFilters adds the given metadata filters to Glob.
+// Glob fails before reading anything if one of them is not valid.
+//
+// Also check WithFilter.
+func WithFilters(filters ...Filter) OptFunc {
+	return func(opts *globOptions) {
+		for _, filter := range filters {
+			if err := filter.Validate(); err != nil {
+				opts.setErr(err)
+				return
+			}
+			opts.filters = append(opts.filters, filter.Match)
+		}
+	}
+}
+
+// Validate reports whether the filter has a known type, and the fields that
+// type needs.
+func (f Filter) Validate() error {
+	switch f.Type {
+	case FilterSizeAtLeast, FilterSizeAtMost, FilterModifiedAfter, FilterModifiedBefore,
+		FilterModeMatches, FilterExecutable:
+		return nil
+	case FilterOwnedBy, FilterNotOwnedBy, FilterGroupOwnedBy:
+		if f.ID == nil {
+			return fmt.Errorf("%s filter: missing id", f.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown filter type: %q", f.Type)
+	}
+}
+
+// Match reports whether the given entry passes the filter.
+// It has the signature of a FilterFunc, and only calls d.Info() when needed.
+func (f Filter) Match(path string, d fs.DirEntry) (bool, error) {
+	if err := f.Validate(); err != nil {
+		return false, err
+	}
+	if f.Type == FilterExecutable && !d.Type().IsRegular() {
+		return false, nil
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		return false, fmt.Errorf("filter %s: %w", path, err)
+	}
+
+	//nolint:exhaustive
+	switch f.Type {
+	case FilterSizeAtLeast:
+		return info.Size() >= f.Size, nil
+	case FilterSizeAtMost:
+		return info.Size() <= f.Size, nil
+	case FilterModifiedAfter:
+		return info.ModTime().After(f.Time), nil
+	case FilterModifiedBefore:
+		return info.ModTime().Before(f.Time), nil
+	case FilterExecutable:
+		return info.Mode().Perm()&0o111 != 0, nil
+	case FilterOwnedBy:
+		uid, _, ok := fileOwner(info)
+		return !ok || uid == *f.ID, nil
+	case FilterNotOwnedBy:
+		uid, _, ok := fileOwner(info)
+		return !ok || uid != *f.ID, nil
+	case FilterGroupOwnedBy:
+		_, gid, ok := fileOwner(info)
+		return !ok || gid == *f.ID, nil
+	default:
+		return info.Mode()&fs.FileMode(f.Mode) == fs.FileMode(f.Mode), nil
+	}
+}
@@ -0,0 +1,209 @@
+package fileglob
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/caarlos0/testfs"
+	"github.com/matryer/is"
+)
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	fsys := testFs(t, []string{
+		"a",
+		"bbb",
+		"ccccc",
+	}, nil)
+	root := fsys.(testfs.FS).Path()
+	for i, name := range []string{"a", "bbb", "ccccc"} {
+		mtime := now.Add(time.Duration(-i) * time.Hour)
+		is.New(t).NoErr(os.Chtimes(filepath.Join(root, name), mtime, mtime))
+	}
+	for name, mode := range map[string]fs.FileMode{"a": 0o644, "bbb": 0o755, "ccccc": 0o640} {
+		is.New(t).NoErr(os.Chmod(filepath.Join(root, name), mode))
+	}
+
+	testCases := []struct {
+		name    string
+		filters []Filter
+		matches []string
+		perms   bool
+	}{
+		{"size at least", []Filter{SizeAtLeast(3)}, []string{"bbb", "ccccc"}, false},
+		{"size at most", []Filter{SizeAtMost(3)}, []string{"a", "bbb"}, false},
+		{"size between", []Filter{SizeAtLeast(2), SizeAtMost(4)}, []string{"bbb"}, false},
+		{"modified after", []Filter{ModifiedAfter(now.Add(-90 * time.Minute))}, []string{"a", "bbb"}, false},
+		{"modified before", []Filter{ModifiedBefore(now.Add(-30 * time.Minute))}, []string{"bbb", "ccccc"}, false},
+		{"mode matches type", []Filter{ModeMatches(fs.ModeDir)}, []string{}, false},
+		{"mode matches", []Filter{ModeMatches(0o644)}, []string{"a", "bbb"}, true},
+		{"executable", []Filter{Executable()}, []string{"bbb"}, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			if testCase.perms && isWindows() {
+				t.Skip("permission bits are not supported on Windows")
+			}
+			is := is.New(t)
+			matches, err := Glob("[a-z]*", WithFs(fsys), WithFilters(testCase.filters...))
+			is.NoErr(err)
+			if matches == nil {
+				matches = []string{}
+			}
+			is.Equal(testCase.matches, matches)
+		})
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		counting := &countingFS{FS: fsys}
+		_, err := Glob("[a-z]*", WithFs(counting), WithFilters(Filter{Type: "nope"}))
+		is.True(err != nil) // expected an error
+		is.Equal(err.Error(), `unknown filter type: "nope"`)
+		is.Equal(int64(0), counting.readDirs.Load()+counting.stats.Load()) // should fail before reading anything
+		is.Equal(err, ValidPattern("*", WithFilters(SizeAtLeast(1), Filter{Type: "nope"})))
+	})
+}
+
+func TestFilterJSON(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	filters := []Filter{
+		SizeAtLeast(10),
+		ModifiedAfter(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Executable(),
+		ModeMatches(0o644),
+	}
+
+	bts, err := json.Marshal(filters)
+	is.NoErr(err)
+	is.Equal(`[{"type":"size_at_least","size":10},`+
+		`{"type":"modified_after","time":"2024-01-02T03:04:05Z"},`+
+		`{"type":"executable"},`+
+		`{"type":"mode_matches","mode":"-rw-r--r--"}]`, string(bts))
+
+	var decoded []Filter
+	is.NoErr(json.Unmarshal(bts, &decoded))
+	is.Equal(filters, decoded)
+}
+
+func TestFilterMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		text string
+		mode fs.FileMode
+	}{
+		{"-rw-r--r--", 0o644},
+		{"----------", 0},
+		{"drwxr-x---", fs.ModeDir | 0o750},
+		{"L---------", fs.ModeSymlink},
+		{"dugrwxrwxrwx", fs.ModeDir | fs.ModeSetuid | fs.ModeSetgid | 0o777},
+		{"0644", 0o644},
+		{"0o755", 0o755},
+		{"420", 0o644},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.text, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			var mode FilterMode
+			is.NoErr(mode.UnmarshalText([]byte(testCase.text)))
+			is.Equal(FilterMode(testCase.mode), mode)
+
+			text, err := mode.MarshalText()
+			is.NoErr(err)
+			is.Equal(testCase.mode.String(), string(text))
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var filter Filter
+		is.NoErr(json.Unmarshal([]byte(`{"type":"mode_matches","mode":420}`), &filter))
+		is.Equal(ModeMatches(0o644), filter)
+		is.NoErr(json.Unmarshal([]byte(`{"type":"mode_matches","mode":"0755"}`), &filter))
+		is.Equal(ModeMatches(0o755), filter)
+		is.True(json.Unmarshal([]byte(`{"type":"mode_matches","mode":true}`), &filter) != nil)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, text := range []string{"", "rw-r--r--", "-rw-r--r-x-", "x---------", "-rw-r--r-?"} {
+			var mode FilterMode
+			is.New(t).True(mode.UnmarshalText([]byte(text)) != nil) // expected an error
+		}
+	})
+}
+
+func TestOwnerFilters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("real", func(t *testing.T) {
+		t.Parallel()
+		if isWindows() {
+			t.Skip("file ownership is not supported on Windows")
+		}
+
+		fsys := testFs(t, []string{"a", "b/c"}, nil)
+		uid, gid := uint32(os.Getuid()), uint32(os.Getgid()) //nolint:gosec
+		testCases := []struct {
+			name    string
+			filter  Filter
+			matches []string
+		}{
+			{"owned by", OwnedBy(uid), []string{"a", "b/c"}},
+			{"owned by other", OwnedBy(uid + 1), []string{}},
+			{"not owned by", NotOwnedBy(uid), []string{}},
+			{"not owned by other", NotOwnedBy(uid + 1), []string{"a", "b/c"}},
+			{"group owned by", GroupOwnedBy(gid), []string{"a", "b/c"}},
+			{"group owned by other", GroupOwnedBy(gid + 1), []string{}},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				t.Parallel()
+				is := is.New(t)
+				matches, err := Glob("{*,*/*}", WithFs(fsys), WithFilters(testCase.filter))
+				is.NoErr(err)
+				if matches == nil {
+					matches = []string{}
+				}
+				is.Equal(testCase.matches, matches)
+			})
+		}
+	})
+
+	t.Run("no ownership information", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("a", WithFs(fstest.MapFS{
+			"a": &fstest.MapFile{},
+		}), WithFilters(OwnedBy(12345), NotOwnedBy(0), GroupOwnedBy(12345)))
+		is.NoErr(err)
+		is.Equal([]string{"a"}, matches)
+	})
+
+	t.Run("missing id", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob("a", WithFs(fstest.MapFS{
+			"a": &fstest.MapFile{},
+		}), WithFilters(Filter{Type: FilterOwnedBy}))
+		is.True(err != nil) // expected an error
+		is.Equal(err.Error(), "owned_by filter: missing id")
+	})
+}
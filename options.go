@@ -0,0 +1,76 @@
+package fileglob
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DirectoryMode is how Glob handles directories matching the pattern.
+type DirectoryMode string
+
+// Directory modes reported by Options.
+const (
+	// DirectoryIncludesContents is the mode set by
+	// MatchDirectoryIncludesContents.
+	DirectoryIncludesContents DirectoryMode = "includes_contents"
+	// DirectoryAsFile is the mode set by MatchDirectoryAsFile.
+	DirectoryAsFile DirectoryMode = "as_file"
+)
+
+// Options are the effective options Glob would use for a pattern.
+type Options struct {
+	// FS is the type of the file system walked, like os.dirFS for the ones
+	// created by os.DirFS.
+	FS string `json:"fs"`
+	// Prefix is what is prepended to the matches, like the root directory
+	// set by MaybeRootFS. It is empty for relative patterns.
+	Prefix string `json:"prefix"`
+	// DirectoryMode is how matching directories are handled.
+	DirectoryMode DirectoryMode `json:"directory_mode"`
+	// Pattern is the pattern relative to the root of the file system.
+	Pattern string `json:"pattern"`
+	// StaticPrefix is the part of Pattern up to the first path element with
+	// a matcher.
+	StaticPrefix string `json:"static_prefix"`
+	// StaticPrefixes are the paths walking starts from. There are more than
+	// one when alternatives allow to walk less than StaticPrefix, like "src"
+	// and "pkg" for "{src,pkg}/**/*.go".
+	StaticPrefixes []string `json:"static_prefixes"`
+}
+
+// ResolveOptions returns the options Glob would use for the given pattern and
+// options, without walking anything.
+func ResolveOptions(pattern string, opts ...OptFunc) (Options, error) {
+	options, pattern, err := resolve(pattern, opts)
+	if err != nil {
+		return Options{}, err
+	}
+	return options.resolved(pattern)
+}
+
+// resolved returns the exported form of the options, for the given pattern
+// relative to the root of the file system.
+func (opts *globOptions) resolved(pattern string) (Options, error) {
+	matcher, err := opts.newMatcher(pattern)
+	if err != nil {
+		return Options{}, err
+	}
+	prefixes, err := opts.walkedPrefixes(pattern, matcher)
+	if err != nil {
+		return Options{}, err
+	}
+
+	mode := DirectoryIncludesContents
+	if opts.matchDirectoriesDirectly {
+		mode = DirectoryAsFile
+	}
+
+	return Options{
+		FS:             fmt.Sprintf("%T", opts.fs),
+		Prefix:         strings.TrimPrefix(opts.prefix, "./"),
+		DirectoryMode:  mode,
+		Pattern:        pattern,
+		StaticPrefix:   matcher.StaticPrefix(),
+		StaticPrefixes: prefixes,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package fileglob
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestResolveOptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("./a/b/*.txt/")
+		is.NoErr(err)
+		is.Equal(Options{
+			FS:             "os.dirFS",
+			DirectoryMode:  DirectoryIncludesContents,
+			Pattern:        "a/b/*.txt",
+			StaticPrefix:   "a/b",
+			StaticPrefixes: []string{"a/b"},
+		}, options)
+	})
+
+	t.Run("with fs", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("a/{b,c}/d", WithFs(fstest.MapFS{}), MatchDirectoryAsFile)
+		is.NoErr(err)
+		is.Equal(Options{
+			FS:             "fstest.MapFS",
+			DirectoryMode:  DirectoryAsFile,
+			Pattern:        "a/{b,c}/d",
+			StaticPrefix:   "a",
+			StaticPrefixes: []string{"a/b/d", "a/c/d"},
+		}, options)
+	})
+
+	t.Run("maybe root fs", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		wd, err := os.Getwd()
+		is.NoErr(err)
+		prefix := "/"
+		if isWindows() {
+			prefix = filepath.VolumeName(wd) + "/"
+		}
+		pattern := toNixPath(filepath.Join(wd, "*_test.go"))
+
+		options, err := ResolveOptions(pattern, MaybeRootFS)
+		is.NoErr(err)
+		static := strings.TrimPrefix(toNixPath(wd), prefix)
+		is.Equal(Options{
+			FS:             "os.dirFS",
+			Prefix:         prefix,
+			DirectoryMode:  DirectoryIncludesContents,
+			Pattern:        strings.TrimPrefix(pattern, prefix),
+			StaticPrefix:   static,
+			StaticPrefixes: []string{static},
+		}, options)
+	})
+
+	t.Run("relative parent", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		wd, err := os.Getwd()
+		is.NoErr(err)
+		options, err := ResolveOptions("../*.go")
+		is.NoErr(err)
+		is.Equal(toNixPath(filepath.Join(filepath.Dir(wd), "*.go")), options.Pattern)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := ResolveOptions("a/[")
+		is.True(err != nil) // expected an error
+		is.Equal(err.Error(), "compile glob pattern: unexpected end of input")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("a/*", WithFs(fstest.MapFS{}))
+		is.NoErr(err)
+		bts, err := json.Marshal(options)
+		is.NoErr(err)
+		is.Equal(
+			`{"fs":"fstest.MapFS","prefix":"","directory_mode":"includes_contents","pattern":"a/*","static_prefix":"a","static_prefixes":["a"]}`,
+			string(bts),
+		)
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package fileglob
+
+import "io/fs"
+
+// fileOwner returns the user and group ids owning the file, which are never
+// available on this platform.
+func fileOwner(fs.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package fileglob
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the user and group ids owning the file, if the file
+// system exposes them.
+func fileOwner(info fs.FileInfo) (uid, gid uint32, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return stat.Uid, stat.Gid, true
+}
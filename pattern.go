@@ -0,0 +1,339 @@
+package fileglob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob/syntax/ast"
+	"github.com/gobwas/glob/syntax/lexer"
+)
+
+// Pattern is a parsed glob pattern, split in its path elements.
+//
+// Printing a Pattern gives back a pattern, which parses to the same Pattern.
+// It is the pattern it was parsed from, unless that one escaped characters
+// that do not need to be, or left a brace open. Parentheses that would start
+// an extended glob operator are escaped, so a Pattern can be parsed back with
+// or without ExtendedGlob.
+type Pattern struct {
+	Segments []Segment
+}
+
+// Segment is a path element of a pattern.
+type Segment struct {
+	Nodes []Node
+}
+
+// Node is a part of a Segment, which is one of Literal, Star, SuperStar,
+// AnyChar, CharClass, Alternatives or ExtGlob.
+type Node interface {
+	// String returns the node as it is written in a pattern.
+	String() string
+	// Describe returns a human readable description of what the node
+	// matches.
+	Describe() string
+
+	node()
+}
+
+// Literal is text matched as is.
+type Literal struct {
+	Text string
+}
+
+// Star is a "*", which matches any number of characters, except path
+// separators.
+type Star struct{}
+
+// SuperStar is a "**", which matches any number of characters, including path
+// separators.
+type SuperStar struct{}
+
+// AnyChar is a "?", which matches a single character, except a path
+// separator.
+type AnyChar struct{}
+
+// CharClass is a "[...]", which matches a single character either in Chars or
+// between Lo and Hi, or not if Negated.
+type CharClass struct {
+	Negated bool
+	Chars   string
+	Lo, Hi  rune
+}
+
+// Alternatives is a "{...,...}", which matches any of its branches. Branches
+// might contain path separators themselves.
+type Alternatives struct {
+	Branches []Pattern
+}
+
+// ParsePattern parses the given pattern. With ExtendedGlob, operators like
+// `@(a|b)` are parsed as ExtGlob nodes instead of literals.
+func ParsePattern(pattern string, opts ...OptFunc) (Pattern, error) {
+	options := compileOptions(opts, pattern)
+	return parsePattern(options.pattern, options.extendedGlob)
+}
+
+func parsePattern(pattern string, extended bool) (Pattern, error) {
+	if err := checkNUL(pattern); err != nil {
+		return Pattern{}, fmt.Errorf("parse glob pattern: %w", err)
+	}
+	pattern, err := expandPOSIXClasses(pattern)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("parse glob pattern: %w", err)
+	}
+	if extended {
+		p, err := parseExtended(pattern)
+		if err != nil {
+			return Pattern{}, fmt.Errorf("parse glob pattern: %w", err)
+		}
+		return p, nil
+	}
+
+	root, err := ast.Parse(lexer.NewLexer(pattern))
+	if err != nil {
+		return Pattern{}, fmt.Errorf("parse glob pattern: %w", err)
+	}
+	return convertPattern(root), nil
+}
+
+func convertPattern(node *ast.Node) Pattern {
+	p := Pattern{Segments: []Segment{{}}}
+	add := func(n Node) {
+		last := &p.Segments[len(p.Segments)-1]
+		last.Nodes = append(last.Nodes, n)
+	}
+
+	for _, child := range node.Children {
+		//nolint:exhaustive
+		switch child.Kind {
+		case ast.KindText:
+			text, _ := child.Value.(ast.Text)
+			for i, part := range strings.Split(text.Text, separatorString) {
+				if i > 0 {
+					p.Segments = append(p.Segments, Segment{})
+				}
+				if part != "" {
+					add(Literal{Text: part})
+				}
+			}
+		case ast.KindAny:
+			add(Star{})
+		case ast.KindSuper:
+			add(SuperStar{})
+		case ast.KindSingle:
+			add(AnyChar{})
+		case ast.KindList:
+			list, _ := child.Value.(ast.List)
+			add(CharClass{Negated: list.Not, Chars: list.Chars})
+		case ast.KindRange:
+			r, _ := child.Value.(ast.Range)
+			add(CharClass{Negated: r.Not, Lo: r.Lo, Hi: r.Hi})
+		case ast.KindAnyOf:
+			var alt Alternatives
+			for _, branch := range child.Children {
+				alt.Branches = append(alt.Branches, convertPattern(branch))
+			}
+			add(alt)
+		}
+	}
+	return p
+}
+
+// literal returns the unquoted text of the pattern, unless it contains
+// matchers.
+func (p Pattern) literal() (string, bool) {
+	var sb strings.Builder
+	for i, segment := range p.Segments {
+		if i > 0 {
+			sb.WriteString(separatorString)
+		}
+		for _, n := range segment.Nodes {
+			l, ok := n.(Literal)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(l.Text)
+		}
+	}
+	return sb.String(), true
+}
+
+// String returns the pattern.
+func (p Pattern) String() string {
+	var sb strings.Builder
+	p.write(&sb, "")
+	return sb.String()
+}
+
+// write writes the pattern to sb. Inside of a group, the characters closing it
+// must be escaped in literals as well.
+func (p Pattern) write(sb *strings.Builder, closers string) {
+	for i, segment := range p.Segments {
+		if i > 0 {
+			sb.WriteString(separatorString)
+		}
+		segment.write(sb, closers)
+	}
+}
+
+// Describe returns a human readable description of what the pattern matches,
+// one path element per line.
+func (p Pattern) Describe() string {
+	var sb strings.Builder
+	for i, segment := range p.Segments {
+		if i == 0 && len(p.Segments) > 1 && len(segment.Nodes) == 0 {
+			sb.WriteString("the root directory\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "path element %d: %s\n", i+1, segment.Describe())
+	}
+	return sb.String()
+}
+
+// String returns the path element as it is written in a pattern.
+func (s Segment) String() string {
+	var sb strings.Builder
+	s.write(&sb, "")
+	return sb.String()
+}
+
+func (s Segment) write(sb *strings.Builder, closers string) {
+	for i, n := range s.Nodes {
+		switch n := n.(type) {
+		case Literal:
+			afterOp := false
+			if i > 0 {
+				switch s.Nodes[i-1].(type) {
+				case Star, AnyChar:
+					afterOp = true
+				}
+			}
+			n.write(sb, closers, afterOp)
+		case Alternatives:
+			n.write(sb)
+		case ExtGlob:
+			n.write(sb)
+		default:
+			sb.WriteString(n.String())
+		}
+	}
+}
+
+// Describe returns a human readable description of what the path element
+// matches.
+func (s Segment) Describe() string {
+	if len(s.Nodes) == 0 {
+		return "nothing"
+	}
+	parts := make([]string, len(s.Nodes))
+	for i, n := range s.Nodes {
+		parts[i] = n.Describe()
+	}
+	return strings.Join(parts, ", followed by ")
+}
+
+func (l Literal) String() string {
+	var sb strings.Builder
+	l.write(&sb, "", false)
+	return sb.String()
+}
+
+// write writes the literal to sb, escaping what would otherwise be parsed as
+// matchers, including the parentheses of extended glob operators: the ones
+// following a "!", "@" or "+" of the literal, or a "*" or "?" right before it.
+// Path separators are escaped as well, as only extended glob patterns have
+// them inside of literals, from a `\/` that must not end the path element.
+func (l Literal) write(sb *strings.Builder, closers string, afterOp bool) {
+	special := `*?[{\/` + closers
+	for i, r := range l.Text {
+		escape := strings.ContainsRune(special, r)
+		if r == '(' {
+			escape = i == 0 && afterOp || i > 0 && strings.IndexByte("!@+", l.Text[i-1]) >= 0
+		}
+		if escape {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (l Literal) Describe() string { return fmt.Sprintf("%q", l.Text) }
+
+func (Star) String() string { return "*" }
+
+func (Star) Describe() string { return "any number of characters, except path separators" }
+
+func (SuperStar) String() string { return "**" }
+
+func (SuperStar) Describe() string { return "any number of characters, including path separators" }
+
+func (AnyChar) String() string { return "?" }
+
+func (AnyChar) Describe() string { return "a single character, except a path separator" }
+
+func (c CharClass) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	if c.Negated {
+		sb.WriteByte('!')
+	}
+	if c.Chars == "" {
+		sb.WriteRune(c.Lo)
+		sb.WriteByte('-')
+		sb.WriteRune(c.Hi)
+	}
+	for i, r := range []rune(c.Chars) {
+		// a leading "!" would negate the class, and a "-" right after the
+		// first character would make it a range
+		if r == '\\' || r == ']' || i == 0 && r == '!' && !c.Negated || i == 1 && r == '-' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (c CharClass) Describe() string {
+	not := ""
+	if c.Negated {
+		not = "not "
+	}
+	if c.Chars == "" {
+		return fmt.Sprintf("a single character %sbetween %q and %q", not, c.Lo, c.Hi)
+	}
+	return fmt.Sprintf("a single character %sin %q", not, c.Chars)
+}
+
+func (a Alternatives) String() string {
+	var sb strings.Builder
+	a.write(&sb)
+	return sb.String()
+}
+
+func (a Alternatives) write(sb *strings.Builder) {
+	sb.WriteByte('{')
+	for i, branch := range a.Branches {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		branch.write(sb, ",}")
+	}
+	sb.WriteByte('}')
+}
+
+func (a Alternatives) Describe() string {
+	branches := make([]string, len(a.Branches))
+	for i, branch := range a.Branches {
+		branches[i] = fmt.Sprintf("%q", branch.String())
+	}
+	return "any of " + strings.Join(branches, ", ")
+}
+
+func (Literal) node()      {}
+func (Star) node()         {}
+func (SuperStar) node()    {}
+func (AnyChar) node()      {}
+func (CharClass) node()    {}
+func (Alternatives) node() {}
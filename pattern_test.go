@@ -0,0 +1,112 @@
+package fileglob
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParsePattern(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected Pattern
+	}{
+		{"", Pattern{Segments: []Segment{{}}}},
+		{"a/b.txt", Pattern{Segments: []Segment{
+			{Nodes: []Node{Literal{Text: "a"}}},
+			{Nodes: []Node{Literal{Text: "b.txt"}}},
+		}}},
+		{"/a/**/*.go", Pattern{Segments: []Segment{
+			{},
+			{Nodes: []Node{Literal{Text: "a"}}},
+			{Nodes: []Node{SuperStar{}}},
+			{Nodes: []Node{Star{}, Literal{Text: ".go"}}},
+		}}},
+		{"file?.[!a-c][xyz]", Pattern{Segments: []Segment{
+			{Nodes: []Node{
+				Literal{Text: "file"},
+				AnyChar{},
+				Literal{Text: "."},
+				CharClass{Negated: true, Lo: 'a', Hi: 'c'},
+				CharClass{Chars: "xyz"},
+			}},
+		}}},
+		{"dist/{a/*.zip,b}", Pattern{Segments: []Segment{
+			{Nodes: []Node{Literal{Text: "dist"}}},
+			{Nodes: []Node{Alternatives{Branches: []Pattern{
+				{Segments: []Segment{
+					{Nodes: []Node{Literal{Text: "a"}}},
+					{Nodes: []Node{Star{}, Literal{Text: ".zip"}}},
+				}},
+				{Segments: []Segment{{Nodes: []Node{Literal{Text: "b"}}}}},
+			}}}},
+		}}},
+		{`\*\{a,b}`, Pattern{Segments: []Segment{
+			{Nodes: []Node{Literal{Text: "*{a,b}"}}},
+		}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			p, err := ParsePattern(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.expected, p)
+			is.Equal(testCase.pattern, p.String())
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParsePattern("[a")
+		is.New(t).True(err != nil) // expected an error
+	})
+}
+
+func TestPatternString(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{
+		"a/b/c",
+		"**",
+		"***",
+		"a**b/*.{go,mod}",
+		"{a,}",
+		`{a\,b,c\}d}`,
+		`[\!a]`,
+		`[!!a]`,
+		`[a\-z]`,
+		`[-a]`,
+		`[\]\\]`,
+		"[]-a]",
+		"[!a-z]",
+		"a,b}",
+		"日本/[語本]*",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			p, err := ParsePattern(pattern)
+			is.NoErr(err)
+			is.Equal(pattern, p.String())
+			again, err := ParsePattern(p.String())
+			is.NoErr(err)
+			is.Equal(p, again)
+		})
+	}
+}
+
+func TestPatternDescribe(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+	p, err := ParsePattern("/dist/*/[a-z]?.{zip,tar.gz}")
+	is.NoErr(err)
+	is.Equal(`the root directory
+path element 2: "dist"
+path element 3: any number of characters, except path separators
+path element 4: a single character between 'a' and 'z', followed by a single character, except a path separator, followed by ".", followed by any of "zip", "tar.gz"
+`, p.Describe())
+}
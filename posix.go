@@ -0,0 +1,160 @@
+package fileglob
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// posixClasses are the characters of the supported POSIX bracket classes, in
+// the C locale. Path separators are left out, as they are never matched by
+// a bracket expression.
+var posixClasses = map[string]string{
+	"alpha":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digit":  "0123456789",
+	"alnum":  "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"space":  " \t\n\v\f\r",
+	"punct":  "!\"#$%&'()*+,-.:;<=>?@[\\]^_`{|}~",
+	"xdigit": "0123456789ABCDEFabcdef",
+}
+
+// maxClassChars is the maximum number of characters a bracket expression with
+// POSIX classes can be rewritten into.
+const maxClassChars = 1024
+
+// expandPOSIXClasses rewrites the bracket expressions of the pattern that
+// contain POSIX classes, like "[[:digit:]_]", into the equivalent lists of
+// characters, like "[0123456789_]", which is what gobwas supports. Ranges in
+// those expressions are rewritten into characters as well, so they can be
+// mixed with classes, like in "[a-f[:digit:]]".
+//
+// Bracket expressions without POSIX classes are left as they are.
+func expandPOSIXClasses(pattern string) (string, error) {
+	if !strings.Contains(pattern, "[:") {
+		return pattern, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			end := min(i+2, len(pattern))
+			sb.WriteString(pattern[i:end])
+			i = end - 1
+		case '[':
+			class, end, err := posixClass(pattern, i)
+			if err != nil {
+				return "", err
+			}
+			if class == "" {
+				end = classEnd(pattern, i)
+				class = pattern[i:end]
+			}
+			sb.WriteString(class)
+			i = end - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// posixClass returns the rewritten bracket expression starting at start, and
+// the index right after it, if it contains POSIX classes. Otherwise, it
+// returns an empty string.
+func posixClass(pattern string, start int) (string, int, error) { //nolint:cyclop
+	var (
+		chars   []rune
+		negated bool
+		posix   bool
+	)
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		negated = true
+		i++
+	}
+
+	for i < len(pattern) {
+		if pattern[i] == ']' {
+			if !posix {
+				return "", 0, nil
+			}
+			return writeClass(chars, negated), i + 1, nil
+		}
+
+		if strings.HasPrefix(pattern[i:], "[:") {
+			end := strings.Index(pattern[i+2:], ":]")
+			if end < 0 {
+				return "", 0, nil
+			}
+			name := pattern[i+2 : i+2+end]
+			members, ok := posixClasses[name]
+			if !ok {
+				return "", 0, fmt.Errorf("unknown character class [:%s:]", name)
+			}
+			chars = append(chars, []rune(members)...)
+			posix = true
+			i += end + 4
+			continue
+		}
+
+		lo, size := classChar(pattern[i:])
+		i += size
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' && !strings.HasPrefix(pattern[i+1:], "[:") {
+			hi, size := classChar(pattern[i+1:])
+			i += size + 1
+			if hi < lo {
+				return "", 0, fmt.Errorf("hi character '%c' should be greater than lo '%c'", hi, lo)
+			}
+			if int(hi-lo) >= maxClassChars {
+				return "", 0, fmt.Errorf("range %c-%c has more than %d characters", lo, hi, maxClassChars)
+			}
+			for r := lo; r <= hi; r++ {
+				chars = append(chars, r)
+			}
+			continue
+		}
+		chars = append(chars, lo)
+	}
+	return "", 0, nil
+}
+
+// classChar returns the possibly escaped character at the start of s, and its
+// size in s.
+func classChar(s string) (rune, int) {
+	if len(s) > 1 && s[0] == '\\' {
+		r, size := utf8.DecodeRuneInString(s[1:])
+		return r, size + 1
+	}
+	return utf8.DecodeRuneInString(s)
+}
+
+// writeClass writes a list of characters that gobwas parses as such, escaping
+// everything that is not a letter or a digit. gobwas reads a class as a range
+// when its first character is followed by "-", even the backslash of `[\-0]`,
+// so "-" is written first, and as is.
+func writeClass(chars []rune, negated bool) string {
+	slices.Sort(chars)
+	chars = slices.Compact(chars)
+
+	var sb strings.Builder
+	sb.WriteByte('[')
+	if negated {
+		sb.WriteByte('!')
+	}
+	if i := slices.Index(chars, '-'); i >= 0 {
+		sb.WriteByte('-')
+		chars = slices.Delete(chars, i, i+1)
+	}
+	for _, r := range chars {
+		if !strings.ContainsRune(posixClasses["alnum"], r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,119 @@
+package fileglob
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestExpandPOSIXClasses(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"[[:digit:]]*.log", "[0123456789]*.log"},
+		{"[![:digit:]]", "[!0123456789]"},
+		{"[[:xdigit:]]", "[0123456789ABCDEFabcdef]"},
+		{"[[:digit:]_-]", `[-0123456789\_]`},
+		{"[[:digit:]-]", "[-0123456789]"},
+		{"[![:digit:]-]", "[!-0123456789]"},
+		{"[a-c[:digit:]]", "[0123456789abc]"},
+		{`[[:digit:]\]]`, `[0123456789\]]`},
+		{"[[:space:]]", "[\\\t\\\n\\\v\\\f\\\r\\ ]"},
+		{"[[:upper:]][[:lower:]]", "[ABCDEFGHIJKLMNOPQRSTUVWXYZ][abcdefghijklmnopqrstuvwxyz]"},
+		{"{[[:digit:]],x}", "{[0123456789],x}"},
+		{"[a-z]", "[a-z]"},
+		{"[:digit:]", "[:digit:]"},
+		{`\[[:digit:]]`, `\[[:digit:]]`},
+		{"[[:digit:]", "[[:digit:]"},
+		{"[[:digit", "[[:digit"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expanded, err := expandPOSIXClasses(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.expected, expanded)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		for _, pattern := range []string{"[[:foo:]]", "[z-a[:digit:]]", "[Ā-￿[:digit:]]"} {
+			_, err := expandPOSIXClasses(pattern)
+			is.New(t).True(err != nil) // expected an error
+		}
+	})
+}
+
+func TestGlobPOSIXClasses(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"1.log":        {},
+		"a.log":        {},
+		"README":       {},
+		"Readme.md":    {},
+		"x/2.log":      {},
+		"x/{}.log":     {},
+		"hello world!": {},
+		"d/1":          {},
+		"d/-":          {},
+		"d/a":          {},
+		"my-file_1.go": {},
+	}
+
+	testCases := []struct {
+		pattern  string
+		opts     []OptFunc
+		expected []string
+	}{
+		{"[[:digit:]]*.log", nil, []string{"1.log"}},
+		{"**/[[:digit:]].log", nil, []string{"x/2.log"}},
+		{"[[:upper:]][[:alnum:]]*", nil, []string{"README", "Readme.md"}},
+		{"[[:upper:]][[:upper:]]*", nil, []string{"README"}},
+		{"*[[:space:]]*[[:punct:]]", nil, []string{"hello world!"}},
+		{"x/[[:punct:]]*", nil, []string{"x/{}.log"}},
+		{"x/[![:digit:]]*", nil, []string{"x/{}.log"}},
+		{"@([[:digit:]]|a).log", []OptFunc{ExtendedGlob}, []string{"1.log", "a.log"}},
+		{"d/[[:digit:]-]", nil, []string{"d/-", "d/1"}},
+		{"d/[![:digit:]-]", nil, []string{"d/a"}},
+		{"d/[[:digit:]-]", []OptFunc{ExtendedGlob}, []string{"d/-", "d/1"}},
+		{"[[:alnum:]._-]*.go", nil, []string{"my-file_1.go"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			matches, err := Glob(testCase.pattern, append([]OptFunc{WithFs(fsys)}, testCase.opts...)...)
+			is.NoErr(err)
+			is.Equal(testCase.expected, matches)
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		is.NoErr(ValidPattern("[[:digit:]]*.log"))
+		is.NoErr(ValidPattern("[[:upper:]][[:alnum:]]*", ExtendedGlob))
+		is.NoErr(ValidPattern("[[:alnum:]._-]*"))
+		is.NoErr(ValidPattern("[[:alnum:]._-]*", ExtendedGlob))
+		is.NoErr(ValidPattern("d/[[:digit:]-]"))
+		is.True(ValidPattern("[[:digits:]]") != nil)               // unknown class
+		is.True(ValidPattern("[[:digits:]]", ExtendedGlob) != nil) // unknown class
+		is.True(ContainsMatchers("[[:digit:]]"))
+		is.True(!ContainsMatchers("[[:digits:]]"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := Glob("[[:digits:]]", WithFs(fsys))
+		is.New(t).True(err != nil) // expected an error
+	})
+}
@@ -1,23 +1,86 @@
 package fileglob
 
 import (
+	"errors"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/gobwas/glob/syntax/ast"
 	"github.com/gobwas/glob/syntax/lexer"
 )
 
+// errNULByte is returned for patterns with a NUL byte, which gobwas takes as
+// their end, and which no path can contain anyway.
+var errNULByte = errors.New("pattern contains a NUL byte")
+
+// checkNUL returns errNULByte if the pattern contains a NUL byte.
+func checkNUL(pattern string) error {
+	if strings.IndexByte(pattern, 0) >= 0 {
+		return errNULByte
+	}
+	return nil
+}
+
 // ValidPattern determines whether a pattern is valid. It returns the parser
 // error if the pattern is invalid and nil otherwise.
-func ValidPattern(pattern string) error {
-	_, err := ast.Parse(lexer.NewLexer(pattern))
+//
+// With WithMatcher, the pattern is valid if the matcher can be created for it.
+// Errors of other options, like a variable ExpandEnv can not expand, are
+// returned as well.
+func ValidPattern(pattern string, opts ...OptFunc) error {
+	options := compileOptions(opts, pattern)
+	if options.err != nil {
+		return options.err
+	}
+	if options.matcher != nil {
+		_, err := options.matcher(options.pattern)
+		return err
+	}
+	if err := checkNUL(options.pattern); err != nil {
+		return err
+	}
+	pattern, err := expandPOSIXClasses(options.pattern)
+	if err != nil {
+		return err
+	}
+	pattern, err = expandSequences(pattern)
+	if err != nil {
+		return err
+	}
+	if options.extendedGlob {
+		_, err := parseExtended(pattern)
+		return err
+	}
+	_, err = ast.Parse(lexer.NewLexer(pattern))
 	return err //nolint:wrapcheck
 }
 
 // ContainsMatchers determines whether the pattern contains any type of glob
 // matcher. It will also return false if the pattern is an invalid expression.
-func ContainsMatchers(pattern string) bool {
+// With ExtendedGlob, operators like `!(a)` are matchers as well.
+func ContainsMatchers(pattern string, opts ...OptFunc) bool {
+	options := compileOptions(opts, pattern)
+	return containsMatchers(options.pattern, options.extendedGlob)
+}
+
+func containsMatchers(pattern string, extended bool) bool {
+	if checkNUL(pattern) != nil {
+		return false
+	}
+	pattern, err := expandPOSIXClasses(pattern)
+	if err != nil {
+		return false
+	}
+	if extended {
+		p, err := parseExtended(pattern)
+		if err != nil {
+			return false
+		}
+		_, isStatic := p.literal()
+		return !isStatic
+	}
+
 	rootNode, err := ast.Parse(lexer.NewLexer(pattern))
 	if err != nil {
 		return false
@@ -27,6 +90,26 @@ func ContainsMatchers(pattern string) bool {
 	return !isStatic
 }
 
+// staticPartText returns the unquoted text of a path element of a pattern,
+// unless it contains matchers.
+func staticPartText(part string, extended bool) (string, bool, error) {
+	if extended {
+		p, err := parseExtended(part)
+		if err != nil {
+			return "", false, fmt.Errorf("parse glob pattern: %w", err)
+		}
+		text, ok := p.literal()
+		return text, ok, nil
+	}
+
+	rootNode, err := ast.Parse(lexer.NewLexer(part))
+	if err != nil {
+		return "", false, fmt.Errorf("parse glob pattern: %w", err)
+	}
+	text, ok := staticText(rootNode)
+	return text, ok, nil
+}
+
 // staticText returns the static string matcher represented by the AST unless
 // it contains dynamic matchers (wildcards, etc.). In this case the ok return
 // value is false.
@@ -59,38 +142,90 @@ func staticText(node *ast.Node) (text string, ok bool) {
 	}
 }
 
+// SplitPattern splits the pattern in its base directory, up to the first path
+// element that contains a matcher, and the rest of the pattern.
+//
+// The base directory is cleaned and has its escaped characters unquoted, so
+// it can be used as a path directly, while rest is still a pattern. If the
+// pattern contains no matchers, base is the whole path and rest is empty.
+func SplitPattern(pattern string, opts ...OptFunc) (base, rest string, err error) {
+	if err := ValidPattern(pattern, opts...); err != nil {
+		return "", "", fmt.Errorf("parse glob pattern: %w", err)
+	}
+
+	options := compileOptions(opts, pattern)
+	pattern = options.pattern
+	parts := splitParts(pattern)
+	static, n, err := staticParts(parts, options.extendedGlob)
+	if err != nil {
+		return "", "", err
+	}
+
+	base = strings.Join(static, separatorString)
+	if strings.HasPrefix(pattern, separatorString) {
+		base = separatorString + base
+	}
+	return path.Clean(base), strings.Join(parts[n:], separatorString), nil
+}
+
 // staticPrefix returns the file path inside the pattern up
 // to the first path element that contains a wildcard.
-func staticPrefix(pattern string) (string, error) {
-	parts := strings.Split(pattern, separatorString)
+func staticPrefix(pattern string, extended bool) (string, error) {
+	prefixPath, _, err := staticParts(splitParts(pattern), extended)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := strings.Join(prefixPath, separatorString)
+	if len(pattern) > 0 && rune(pattern[0]) == separatorRune && !strings.HasPrefix(prefix, separatorString) {
+		prefix = separatorString + prefix
+	}
+
+	if prefix == "" {
+		prefix = "."
+	}
+
+	return prefix, nil
+}
 
+// staticParts returns the unquoted text of the non-empty path elements up to
+// the first one that contains a wildcard, and the index of that one.
+func staticParts(parts []string, extended bool) ([]string, int, error) {
 	//nolint:prealloc
-	var prefixPath []string
-	for _, part := range parts {
+	var static []string
+	for i, part := range parts {
 		if part == "" {
 			continue
 		}
 
-		rootNode, err := ast.Parse(lexer.NewLexer(part))
+		staticPart, ok, err := staticPartText(part, extended)
 		if err != nil {
-			return "", fmt.Errorf("parse glob pattern: %w", err)
+			return nil, 0, err
 		}
-
-		staticPart, ok := staticText(rootNode)
 		if !ok {
-			break
+			return static, i, nil
 		}
 
-		prefixPath = append(prefixPath, staticPart)
-	}
-	prefix := strings.Join(prefixPath, separatorString)
-	if len(pattern) > 0 && rune(pattern[0]) == separatorRune && !strings.HasPrefix(prefix, separatorString) {
-		prefix = separatorString + prefix
+		static = append(static, staticPart)
 	}
+	return static, len(parts), nil
+}
 
-	if prefix == "" {
-		prefix = "."
+// splitParts splits the pattern at its path separators, except for the ones
+// inside of character classes, which match them.
+func splitParts(pattern string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			i = classEnd(pattern, i) - 1
+		case separatorRune:
+			parts = append(parts, pattern[start:i])
+			start = i + 1
+		}
 	}
-
-	return prefix, nil
+	return append(parts, pattern[start:])
 }
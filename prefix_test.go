@@ -1,38 +1,67 @@
 package fileglob
 
 import (
+	"io/fs"
+	"slices"
 	"testing"
+	"testing/fstest"
 
 	"github.com/gobwas/glob/syntax/ast"
 	"github.com/gobwas/glob/syntax/lexer"
 	"github.com/matryer/is"
 )
 
+var prefixTestCases = []struct {
+	pattern string
+	prefix  string
+	base    string
+	rest    string
+}{
+	{"/foo/b*ar/baz", "/foo", "/foo", "b*ar/baz"},
+	{"foo/bar", "foo/bar", "foo/bar", ""},
+	{"/foo/bar/{b,p}az", "/foo/bar", "/foo/bar", "{b,p}az"},
+	{"*/foo", ".", ".", "*/foo"},
+	{"./", ".", ".", ""},
+	{"./foo/*", "./foo", "foo", "*"},
+	{"../foo/*/bar", "../foo", "../foo", "*/bar"},
+	{"foo//bar/", "foo/bar", "foo/bar", ""},
+	{"fo\\*o/bar/b*z", "fo*o/bar", "fo*o/bar", "b*z"},
+	{"/\\{foo\\}/bar", "/{foo}/bar", "/{foo}/bar", ""},
+	{"/\\{foo\\}/b\\*/[ab]\\*", "/{foo}/b*", "/{foo}/b*", "[ab]\\*"},
+	{"C:/Path/To/Some/File", "C:/Path/To/Some/File", "C:/Path/To/Some/File", ""},
+}
+
 func TestStaticPrefix(t *testing.T) {
 	t.Parallel()
-	testCases := []struct {
-		pattern string
-		prefix  string
-	}{
-		{"/foo/b*ar/baz", "/foo"},
-		{"foo/bar", "foo/bar"},
-		{"/foo/bar/{b,p}az", "/foo/bar"},
-		{"*/foo", "."},
-		{"./", "."},
-		{"fo\\*o/bar/b*z", "fo*o/bar"},
-		{"/\\{foo\\}/bar", "/{foo}/bar"},
-		{"C:/Path/To/Some/File", "C:/Path/To/Some/File"},
+	for _, testCase := range prefixTestCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			prefix, err := staticPrefix(testCase.pattern, false)
+			is.NoErr(err)
+			is.Equal(testCase.prefix, prefix)
+		})
 	}
+}
 
-	for _, testCase := range testCases {
+func TestSplitPattern(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range prefixTestCases {
 		t.Run(testCase.pattern, func(t *testing.T) {
 			t.Parallel()
 			is := is.New(t)
-			prefix, err := staticPrefix(testCase.pattern)
+			base, rest, err := SplitPattern(testCase.pattern)
 			is.NoErr(err)
-			is.Equal(testCase.prefix, prefix)
+			is.Equal(testCase.base, base)
+			is.Equal(testCase.rest, rest)
 		})
 	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := SplitPattern("foo/[a")
+		is.New(t).True(err != nil) // expected an error
+	})
 }
 
 func TestContainsMatchers(t *testing.T) {
@@ -72,6 +101,9 @@ func TestValidPattern(t *testing.T) {
 		{"/a/*/b", true},
 		{"{a[", false},
 		{"[*]", true},
+		{"a\x00b", false},
+		{"{1..10000}", true},
+		{"{10010..0}", false},
 	}
 
 	for _, testCase := range testCases {
@@ -81,3 +113,122 @@ func TestValidPattern(t *testing.T) {
 		})
 	}
 }
+
+// fuzzOptions are the options the fuzz targets try each pattern with.
+var fuzzOptions = [][]OptFunc{nil, {ExtendedGlob}, {StrictDoublestar}}
+
+func addPrefixSeeds(f *testing.F) {
+	f.Helper()
+	for _, testCase := range prefixTestCases {
+		f.Add(testCase.pattern)
+	}
+	for _, pattern := range []string{
+		"", ".", "*", "**", "a/**/b", "{a,b}/c", "{a/b,c}", "[a-z]/*", "[!a]", `\`, "a/[", "{a",
+		"@(a|b)/c", "!(*_test).go", "[[:digit:]]", "{1..3}", "{a..c}/x", "a(b)", "[/]",
+		"\x00/\x8b", "00/\x000/0", "{A..0}(0", "0{", "0{,", "a{}b",
+	} {
+		f.Add(pattern)
+	}
+}
+
+func FuzzValidPattern(f *testing.F) {
+	addPrefixSeeds(f)
+	f.Fuzz(func(t *testing.T, pattern string) {
+		for _, opts := range fuzzOptions {
+			err := ValidPattern(pattern, opts...)
+			_ = ContainsMatchers(pattern, opts...)
+			_, _, _ = SplitPattern(pattern, opts...)
+			_, _ = ParsePattern(pattern, opts...)
+			_, _ = ExpandBraces(pattern, opts...)
+			if err != nil {
+				continue
+			}
+			if _, err := ResolveOptions(pattern, append([]OptFunc{WithFs(fstest.MapFS{})}, opts...)...); err != nil {
+				t.Errorf("%q is valid, but can not be resolved: %v", pattern, err)
+			}
+		}
+	})
+}
+
+func FuzzStaticPrefix(f *testing.F) {
+	addPrefixSeeds(f)
+	f.Fuzz(func(t *testing.T, pattern string) {
+		for _, opts := range fuzzOptions {
+			options, pattern, err := resolve(pattern, append([]OptFunc{WithFs(fstest.MapFS{})}, opts...))
+			if err != nil {
+				continue
+			}
+			resolved, err := options.resolved(pattern)
+			if err != nil {
+				continue
+			}
+			matcher, err := options.compile(pattern)
+			if err != nil {
+				t.Fatalf("%q resolves, but does not compile: %v", pattern, err)
+			}
+
+			// the paths of the static patterns the pattern expands to are
+			// ones it can match
+			expanded, err := expandBraces(pattern, options.extendedGlob)
+			if err != nil {
+				continue
+			}
+			for _, p := range expanded {
+				parsed, err := parsePattern(p, options.extendedGlob)
+				if err != nil {
+					continue
+				}
+				name, ok := parsed.literal()
+				if !ok || !fs.ValidPath(name) || !matcher.Match(name) {
+					continue
+				}
+				if !isInside(name, resolved.StaticPrefix) {
+					t.Errorf("%q matches %q, outside of its static prefix %q", pattern, name, resolved.StaticPrefix)
+				}
+				if !slices.ContainsFunc(resolved.StaticPrefixes, func(prefix string) bool {
+					return isInside(name, prefix)
+				}) {
+					t.Errorf("%q matches %q, outside of its static prefixes %q", pattern, name, resolved.StaticPrefixes)
+				}
+			}
+		}
+	})
+}
+
+func FuzzQuoteMeta(f *testing.F) {
+	for _, text := range []string{"a", "a/b", "{a,b}", "*", `a\b`, "@(a)", "!(a)", "[[:digit:]]", "{1..3}", "a/**/b", "\x00"} {
+		f.Add(text, "a")
+	}
+	f.Fuzz(func(t *testing.T, text, other string) {
+		if !fs.ValidPath(text) || checkNUL(text) != nil {
+			return
+		}
+		for _, opts := range fuzzOptions {
+			opts = append([]OptFunc{WithFs(fstest.MapFS{}), QuoteMeta}, opts...)
+			if ContainsMatchers(text, opts...) {
+				t.Errorf("quoted %q contains matchers", text)
+			}
+			resolved, err := ResolveOptions(text, opts...)
+			if err != nil {
+				t.Fatalf("quoted %q can not be resolved: %v", text, err)
+			}
+			if resolved.StaticPrefix != text || !slices.Equal(resolved.StaticPrefixes, []string{text}) {
+				t.Errorf("quoted %q has static prefixes %q and %q", text, resolved.StaticPrefix, resolved.StaticPrefixes)
+			}
+			options, pattern, err := resolve(text, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matcher, err := options.compile(pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !matcher.Match(text) {
+				t.Errorf("quoted %q does not match itself", text)
+			}
+			if other != text && matcher.Match(other) {
+				t.Errorf("quoted %q matches %q", text, other)
+			}
+		}
+	})
+}
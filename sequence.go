@@ -0,0 +1,149 @@
+package fileglob
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sequenceRe matches a bash sequence expression, like "{1..10}", "{01..10..2}"
+// or "{a..f}", at the start of a string.
+var sequenceRe = regexp.MustCompile(`^\{(?:(-?[0-9]+)\.\.(-?[0-9]+)|([^\\{}\[\],/])\.\.([^\\{}\[\],/]))(?:\.\.(-?[0-9]+))?\}`)
+
+// expandSequences rewrites the bash sequence expressions of the pattern into
+// the equivalent alternatives, so "{1..3}" becomes "{1,2,3}". Escaped braces
+// and braces inside of character classes are left as they are.
+//
+// Like in bash, a sequence is either of integers or of single characters, and
+// has an optional step. Integers are zero-padded to the same width if either
+// end starts with a zero.
+func expandSequences(pattern string) (string, error) {
+	if !strings.Contains(pattern, "..") {
+		return pattern, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			end := min(i+2, len(pattern))
+			sb.WriteString(pattern[i:end])
+			i = end - 1
+		case '[':
+			end := classEnd(pattern, i)
+			sb.WriteString(pattern[i:end])
+			i = end - 1
+		case '{':
+			match := sequenceRe.FindStringSubmatch(pattern[i:])
+			if match == nil || !isASCIISequence(match) {
+				sb.WriteByte(c)
+				continue
+			}
+			elems, err := sequenceElems(match)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("{" + strings.Join(elems, ",") + "}")
+			i += len(match[0]) - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// classEnd returns the index right after the character class starting at
+// start, or the end of the pattern if it is not closed. Like in the gobwas
+// lexer, a class is either a range, whose ends are never escaped, or a list.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		i++
+	}
+	if i+2 < len(pattern) && pattern[i+1] == '-' {
+		return min(i+4, len(pattern))
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i + 1
+		}
+	}
+	return len(pattern)
+}
+
+// sequenceElems returns the elements of the sequence matched by sequenceRe.
+func sequenceElems(match []string) ([]string, error) {
+	step := 1
+	if match[5] != "" {
+		n, err := strconv.Atoi(match[5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid sequence %s: %w", match[0], err)
+		}
+		step = max(n, -n, 1)
+	}
+
+	var (
+		from, to int
+		format   func(n int) string
+	)
+	if match[1] != "" {
+		var err error
+		if from, err = strconv.Atoi(match[1]); err != nil {
+			return nil, fmt.Errorf("invalid sequence %s: %w", match[0], err)
+		}
+		if to, err = strconv.Atoi(match[2]); err != nil {
+			return nil, fmt.Errorf("invalid sequence %s: %w", match[0], err)
+		}
+		width := 0
+		if isZeroPadded(match[1]) || isZeroPadded(match[2]) {
+			width = max(len(match[1]), len(match[2]))
+		}
+		format = func(n int) string {
+			return fmt.Sprintf("%0*d", width, n)
+		}
+	} else {
+		from, to = int(match[3][0]), int(match[4][0])
+		format = func(n int) string {
+			return quoteSequenceChar(byte(n))
+		}
+	}
+
+	count := (max(from, to)-min(from, to))/step + 1
+	if count > maxExpansions || count < 0 {
+		return nil, fmt.Errorf("%w: %s has more than %d elements", errTooManyExpansions, match[0], maxExpansions)
+	}
+
+	elems := make([]string, 0, count)
+	if from > to {
+		step = -step
+	}
+	for n := from; len(elems) < count; n += step {
+		elems = append(elems, format(n))
+	}
+	return elems, nil
+}
+
+// isASCIISequence reports whether a sequence of characters is between ASCII
+// characters, which are the only ones supported.
+func isASCIISequence(match []string) bool {
+	return match[3] == "" || len(match[3]) == 1 && len(match[4]) == 1
+}
+
+func isZeroPadded(n string) bool {
+	n = strings.TrimPrefix(n, "-")
+	return len(n) > 1 && n[0] == '0'
+}
+
+// quoteSequenceChar escapes the characters of a sequence that have a meaning
+// inside of alternatives, or inside of extended globs with ExtendedGlob, so
+// "{A..0}(0" does not become the operator "@(".
+func quoteSequenceChar(c byte) string {
+	if strings.IndexByte(`*?[]{}\,!@+()|`, c) >= 0 {
+		return `\` + string(c)
+	}
+	return string(c)
+}
@@ -0,0 +1,114 @@
+package fileglob
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestExpandSequences(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"a/*.txt", "a/*.txt"},
+		{"artifact-{1..5}.zip", "artifact-{1,2,3,4,5}.zip"},
+		{"{5..1}", "{5,4,3,2,1}"},
+		{"{-2..2}", "{-2,-1,0,1,2}"},
+		{"{01..10..2}", "{01,03,05,07,09}"},
+		{"{1..010..3}", "{001,004,007,010}"},
+		{"{10..1..-3}", "{10,7,4,1}"},
+		{"{1..3..0}", "{1,2,3}"},
+		{"{a..f}", "{a,b,c,d,e,f}"},
+		{"{z..u..2}", "{z,x,v}"},
+		{"{X..^}", `{X,Y,Z,\[,\\,\],^}`},
+		{"{Y..b}", "{Y,Z,\\[,\\\\,\\],^,_,`,a,b}"},
+		{"{A..0}(0", `{A,\@,\?,>,=,<,;,:,9,8,7,6,5,4,3,2,1,0}(0`},
+		{"{a..c}/{1..2}", "{a,b,c}/{1,2}"},
+		{"{x,{1..3}}", "{x,{1,2,3}}"},
+		{`\{1..3}`, `\{1..3}`},
+		{"[{]1..3}", "[{]1..3}"},
+		{"[{-}]1..3}", "[{-}]1..3}"},
+		{`[\]{]{1..3}`, `[\]{]{1,2,3}`},
+		{"{1..3,4}", "{1..3,4}"},
+		{"{a..10}", "{a..10}"},
+		{"{1.2}", "{1.2}"},
+		{"{é..ü}", "{é..ü}"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			expanded, err := expandSequences(testCase.pattern)
+			is.NoErr(err)
+			is.Equal(testCase.expected, expanded)
+			is.NoErr(ValidPattern(expanded))
+		})
+	}
+
+	t.Run("too many", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := expandSequences("{1..100000}")
+		is.True(errors.Is(err, errTooManyExpansions))
+		is.Equal(err.Error(), "too many brace expansions: {1..100000} has more than 10000 elements")
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		t.Parallel()
+		_, err := expandSequences("{1..99999999999999999999}")
+		is.New(t).True(err != nil) // expected an error
+	})
+}
+
+func TestGlobSequences(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{}
+	for i := range 25 {
+		fsys[fmt.Sprintf("dist/artifact-%d.zip", i)] = &fstest.MapFile{}
+	}
+	fsys["dist/b/artifact-3.zip"] = &fstest.MapFile{}
+
+	t.Run("glob", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		matches, err := Glob("dist/{a..b}*-{18..22..2}.zip", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal([]string{
+			"dist/artifact-18.zip",
+			"dist/artifact-20.zip",
+			"dist/artifact-22.zip",
+		}, matches)
+	})
+
+	t.Run("static prefixes", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		options, err := ResolveOptions("dist/{a..b}/*-{1..3}.zip", WithFs(fsys))
+		is.NoErr(err)
+		is.Equal("dist/{a,b}/*-{1,2,3}.zip", options.Pattern)
+		is.Equal([]string{"dist/a", "dist/b"}, options.StaticPrefixes)
+	})
+
+	t.Run("expand braces", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		expanded, err := ExpandBraces("v{1..2}.{0..1}")
+		is.NoErr(err)
+		is.Equal([]string{"v1.0", "v1.1", "v2.0", "v2.1"}, expanded)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob("dist/artifact-{0..20000}.zip", WithFs(fsys))
+		is.True(errors.Is(err, errTooManyExpansions))
+	})
+}
@@ -0,0 +1,166 @@
+package fileglob
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// SortOrder is the order in which Glob returns its matches.
+type SortOrder int
+
+// Sort orders supported by WithSort.
+const (
+	// SortNone keeps the order in which matches are found, which depends on
+	// the fs.FS implementation. This is the default.
+	SortNone SortOrder = iota
+	// SortLexical sorts paths byte-wise, like sort.Strings.
+	SortLexical
+	// SortNatural sorts paths like SortLexical, but compares runs of digits
+	// by their numeric value, so file2 comes before file10.
+	SortNatural
+	// SortDepthFirst sorts paths element by element, so directories are
+	// followed by their contents, like a lexical fs.WalkDir.
+	SortDepthFirst
+	// SortBreadthFirst sorts shallower paths first, and paths at the same
+	// depth like SortDepthFirst.
+	SortBreadthFirst
+	// SortModTime sorts paths by modification time, oldest first.
+	SortModTime
+	// SortSize sorts paths by size, smallest first.
+	SortSize
+)
+
+func (o SortOrder) String() string {
+	switch o {
+	case SortNone:
+		return "none"
+	case SortLexical:
+		return "lexical"
+	case SortNatural:
+		return "natural"
+	case SortDepthFirst:
+		return "depth_first"
+	case SortBreadthFirst:
+		return "breadth_first"
+	case SortModTime:
+		return "mod_time"
+	case SortSize:
+		return "size"
+	default:
+		return fmt.Sprintf("SortOrder(%d)", int(o))
+	}
+}
+
+// WithSort makes Glob return its matches in the given order, regardless of
+// the order in which the fs.FS implementation lists directories.
+//
+// Matches that are equal for the given order, like files with the same size,
+// are sorted depth first.
+func WithSort(order SortOrder) OptFunc {
+	return func(opts *globOptions) {
+		opts.sort = order
+	}
+}
+
+// sortMatches sorts the given matches, which are relative to the options file
+// system, in place.
+func sortMatches(options *globOptions, matches []string) error {
+	switch options.sort {
+	case SortNone:
+		return nil
+	case SortLexical:
+		slices.Sort(matches)
+		return nil
+	case SortNatural:
+		slices.SortFunc(matches, func(a, b string) int {
+			return cmp.Or(compareNatural(a, b), strings.Compare(a, b))
+		})
+		return nil
+	case SortDepthFirst:
+		slices.SortFunc(matches, compareDepthFirst)
+		return nil
+	case SortBreadthFirst:
+		slices.SortFunc(matches, func(a, b string) int {
+			return cmp.Or(
+				cmp.Compare(strings.Count(a, separatorString), strings.Count(b, separatorString)),
+				compareDepthFirst(a, b),
+			)
+		})
+		return nil
+	case SortModTime, SortSize:
+		infos := make(map[string]fs.FileInfo, len(matches))
+		for _, match := range matches {
+			options.counters.statCalls.Add(1)
+			info, err := fs.Stat(options.fs, match)
+			if err != nil {
+				return fmt.Errorf("sort by %s: %w", options.sort, err)
+			}
+			infos[match] = info
+		}
+		slices.SortFunc(matches, func(a, b string) int {
+			var c int
+			if options.sort == SortModTime {
+				c = infos[a].ModTime().Compare(infos[b].ModTime())
+			} else {
+				c = cmp.Compare(infos[a].Size(), infos[b].Size())
+			}
+			return cmp.Or(c, compareDepthFirst(a, b))
+		})
+		return nil
+	default:
+		return fmt.Errorf("unknown sort order: %s", options.sort)
+	}
+}
+
+// compareDepthFirst compares paths element by element.
+func compareDepthFirst(a, b string) int {
+	for a != "" && b != "" {
+		var ea, eb string
+		ea, a, _ = strings.Cut(a, separatorString)
+		eb, b, _ = strings.Cut(b, separatorString)
+		if c := strings.Compare(ea, eb); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// compareNatural compares strings byte-wise, except for runs of digits, which
+// are compared by their numeric value.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var da, db string
+			da, a = cutDigits(a)
+			db, b = cutDigits(b)
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if c := cmp.Or(
+				cmp.Compare(len(na), len(nb)),
+				strings.Compare(na, nb),
+			); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := cmp.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func cutDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
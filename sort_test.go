@@ -0,0 +1,76 @@
+package fileglob
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithSort(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"a.txt":         {Data: []byte("12345"), ModTime: now.Add(-time.Hour)},
+		"a/file10.txt":  {Data: []byte("1"), ModTime: now.Add(-2 * time.Hour)},
+		"a/file2.txt":   {Data: []byte("123"), ModTime: now},
+		"a/b/file1.txt": {Data: []byte("12"), ModTime: now.Add(-3 * time.Hour)},
+		"file9.txt":     {Data: []byte("1234"), ModTime: now.Add(-4 * time.Hour)},
+	}
+
+	testCases := []struct {
+		order   SortOrder
+		matches []string
+	}{
+		{SortNone, []string{"a/b/file1.txt", "a/file10.txt", "a/file2.txt", "a.txt", "file9.txt"}},
+		{SortLexical, []string{"a.txt", "a/b/file1.txt", "a/file10.txt", "a/file2.txt", "file9.txt"}},
+		{SortNatural, []string{"a.txt", "a/b/file1.txt", "a/file2.txt", "a/file10.txt", "file9.txt"}},
+		{SortDepthFirst, []string{"a/b/file1.txt", "a/file10.txt", "a/file2.txt", "a.txt", "file9.txt"}},
+		{SortBreadthFirst, []string{"a.txt", "file9.txt", "a/file10.txt", "a/file2.txt", "a/b/file1.txt"}},
+		{SortModTime, []string{"file9.txt", "a/b/file1.txt", "a/file10.txt", "a.txt", "a/file2.txt"}},
+		{SortSize, []string{"a/file10.txt", "a/b/file1.txt", "a/file2.txt", "file9.txt", "a.txt"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.order.String(), func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			matches, err := Glob("**.txt", WithFs(fsys), WithSort(testCase.order))
+			is.NoErr(err)
+			is.Equal(testCase.matches, matches)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		_, err := Glob("**.txt", WithFs(fsys), WithSort(SortOrder(42)))
+		is.True(err != nil) // expected an error
+		is.Equal(err.Error(), "unknown sort order: SortOrder(42)")
+	})
+}
+
+func TestCompareNatural(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file02", "file2", 0},
+		{"file", "file1", -1},
+		{"a10b2", "a10b10", -1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"abc", "abd", -1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.a+" "+testCase.b, func(t *testing.T) {
+			t.Parallel()
+			is.New(t).Equal(testCase.want, compareNatural(testCase.a, testCase.b))
+		})
+	}
+}
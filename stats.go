@@ -0,0 +1,65 @@
+package fileglob
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are statistics about a single Glob run.
+type Stats struct {
+	// DirsRead is the number of directories whose entries were read.
+	DirsRead int64
+	// EntriesVisited is the number of files and directories walked.
+	EntriesVisited int64
+	// EntriesMatched is the number of entries that matched the pattern and
+	// passed all filters. It can be higher than the number of results when
+	// WithLimit is used.
+	EntriesMatched int64
+	// SubtreesPruned is the number of times the rest of a directory was not
+	// walked, usually because of a directory filter.
+	SubtreesPruned int64
+	// StatCalls is the number of files stat'ed, besides reading directories.
+	StatCalls int64
+	// ErrorsSkipped is the number of errors that did not fail the Glob,
+	// like a missing static prefix.
+	ErrorsSkipped int64
+	// Elapsed is how long the Glob took.
+	Elapsed time.Duration
+}
+
+// WithStats makes Glob fill s with statistics about its run once it is done,
+// whether it failed or not.
+func WithStats(s *Stats) OptFunc {
+	return func(opts *globOptions) {
+		opts.stats = s
+	}
+}
+
+// counters collect the statistics of a Glob run, which might happen in
+// multiple goroutines.
+type counters struct {
+	start          time.Time
+	dirsRead       atomic.Int64
+	entriesVisited atomic.Int64
+	entriesMatched atomic.Int64
+	subtreesPruned atomic.Int64
+	statCalls      atomic.Int64
+	errorsSkipped  atomic.Int64
+}
+
+func newCounters() *counters {
+	return &counters{start: time.Now()}
+}
+
+// report fills s with the current counters.
+func (c *counters) report(s *Stats) {
+	*s = Stats{
+		DirsRead:       c.dirsRead.Load(),
+		EntriesVisited: c.entriesVisited.Load(),
+		EntriesMatched: c.entriesMatched.Load(),
+		SubtreesPruned: c.subtreesPruned.Load(),
+		StatCalls:      c.statCalls.Load(),
+		ErrorsSkipped:  c.errorsSkipped.Load(),
+		Elapsed:        time.Since(c.start),
+	}
+}
@@ -0,0 +1,95 @@
+package fileglob
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestWithStats(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"a/1.txt":      {},
+		"a/2.go":       {},
+		"b/4.txt":      {},
+		"b/skip/3.txt": {},
+	}
+	skip := WithDirFilter(func(_ string, d fs.DirEntry) (bool, error) {
+		return d.Name() != "skip", nil
+	})
+
+	for _, n := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", n), func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			var stats Stats
+			matches, err := Glob("**/*.txt", WithFs(fsys), skip, WithConcurrency(n), WithStats(&stats))
+			is.NoErr(err)
+			is.Equal([]string{"a/1.txt", "b/4.txt"}, matches)
+			is.True(stats.Elapsed > 0) // should have elapsed time
+			stats.Elapsed = 0
+			is.Equal(Stats{
+				DirsRead:       3,
+				EntriesVisited: 7,
+				EntriesMatched: 2,
+				SubtreesPruned: 1,
+				StatCalls:      2,
+			}, stats)
+		})
+	}
+
+	t.Run("sorted and limited", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var stats Stats
+		matches, err := Glob("**/*.txt", WithFs(fsys), WithSort(SortSize), WithLimit(1), WithStats(&stats))
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt"}, matches)
+		is.Equal(int64(3), stats.EntriesMatched)
+		is.Equal(int64(5), stats.StatCalls)
+	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		stats := Stats{DirsRead: 42}
+		matches, err := Glob("c/*", WithFs(fsys), WithStats(&stats))
+		is.NoErr(err)
+		is.Equal([]string{}, matches)
+		stats.Elapsed = 0
+		is.Equal(Stats{
+			StatCalls:     2,
+			ErrorsSkipped: 1,
+		}, stats)
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var stats Stats
+		matches, err := Glob("a/1.txt", WithFs(fsys), WithStats(&stats))
+		is.NoErr(err)
+		is.Equal([]string{"a/1.txt"}, matches)
+		is.Equal(int64(1), stats.EntriesMatched)
+		is.Equal(int64(0), stats.DirsRead)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		for _, opts := range [][]OptFunc{
+			{ExpandEnvWith(testLookup)},
+			nil,
+		} {
+			stats := Stats{DirsRead: 42}
+			_, err := Glob("$NOPE/[[:nope:]]", append([]OptFunc{WithFs(fsys), WithStats(&stats)}, opts...)...)
+			is.True(err != nil) // expected an error
+			stats.Elapsed = 0
+			is.Equal(Stats{}, stats)
+		}
+	})
+}
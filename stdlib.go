@@ -0,0 +1,84 @@
+package fileglob
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// StdlibCompat makes Glob match like filepath.Glob does, with '/' as the path
+// separator:
+//
+//   - patterns have the syntax of filepath.Match, without "**" or braces
+//   - matching directories are returned, without their contents
+//   - matches are sorted directory by directory
+//   - a malformed pattern fails with filepath.ErrBadPattern
+//   - missing files and unreadable directories are ignored, and no match at
+//     all returns nil
+//
+// Options set after it, like WithSort or WithMatcher, still apply.
+func StdlibCompat(opts *globOptions) {
+	opts.stdlibCompat = true
+	opts.matcher = stdlibMatcher
+	opts.matchDirectoriesDirectly = true
+	opts.sort = SortDepthFirst
+}
+
+// stdlibPathMatcher is a Matcher for the patterns of filepath.Match, which
+// never matches the root directory with a matcher, as it is not listed by
+// reading a directory.
+type stdlibPathMatcher struct {
+	Matcher
+	pattern string
+}
+
+func (m stdlibPathMatcher) Match(name string) bool {
+	if name == "." {
+		return m.pattern == "."
+	}
+	return m.Matcher.Match(name)
+}
+
+func stdlibMatcher(pattern string) (Matcher, error) {
+	m, err := PathMatcher(pattern)
+	if err != nil {
+		return nil, filepath.ErrBadPattern
+	}
+	return stdlibPathMatcher{Matcher: m, pattern: pattern}, nil
+}
+
+// stdlibLstat returns the information about the link itself when following
+// it failed with err, like for a broken or looping symbolic link, as
+// filepath.Glob returns those when a pattern names them without matchers.
+func stdlibLstat(fsys fs.FS, name string, err error) (fs.FileInfo, error) {
+	lfs, ok := fsys.(fs.ReadLinkFS)
+	if !ok {
+		return nil, err
+	}
+	info, lerr := lfs.Lstat(name)
+	if lerr != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return nil, err
+	}
+	return info, nil
+}
+
+// depth returns the number of path elements of name, which is 0 for the root
+// directory.
+func depth(name string) int {
+	if name == "." {
+		return 0
+	}
+	return strings.Count(name, separatorString) + 1
+}
+
+// pruneBelow wraps visit to not enter directories at the given depth, as
+// nothing inside of them can match a pattern without "**".
+func pruneBelow(visit visitFunc, maxDepth int) visitFunc {
+	return func(path string, d fs.DirEntry, inherited bool, matches []string) ([]string, bool, error) {
+		matches, inherit, err := visit(path, d, inherited, matches)
+		if err == nil && d.IsDir() && depth(path) >= maxDepth {
+			return matches, inherit, fs.SkipDir
+		}
+		return matches, inherit, err
+	}
+}
@@ -0,0 +1,196 @@
+package fileglob
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// stdlibNames are what the generated trees and patterns are made of, including
+// characters that are special for one syntax but not the other.
+var stdlibNames = []string{"a", "b", "ab", "ba", ".a", "a.b", "{a,b}", "a**", "[a]", "a b"}
+
+// stdlibSegments are the path elements of the generated patterns.
+var stdlibSegments = []string{
+	"a", "ab", ".a", "*", "?", "a*", "*b", "*.*", "??", "[ab]", "[!a]*", "[a-b]?",
+	`\a`, `\*`, `\[a\]`, "{a,b}", "a**", "**", "[.]*", "[^a]", "a b",
+}
+
+// stdlibLinks are the targets of the symbolic links of the generated trees,
+// which can be missing, files, directories, or loops.
+var stdlibLinks = []string{".", "..", "a", "b", "nope"}
+
+// randomTree creates files, directories and symbolic links with the given
+// names in dir, up to three levels deep.
+func randomTree(t *testing.T, r *rand.Rand, dir string, level int) {
+	t.Helper()
+	for _, name := range stdlibNames {
+		p := filepath.Join(dir, name)
+		switch n := r.IntN(5); {
+		case n == 0:
+			continue
+		case n == 4:
+			if err := os.Symlink(stdlibLinks[r.IntN(len(stdlibLinks))], p); err != nil {
+				t.Fatal(err)
+			}
+		case n == 1 || level == 3:
+			if err := os.WriteFile(p, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			if err := os.Mkdir(p, 0o700); err != nil {
+				t.Fatal(err)
+			}
+			randomTree(t, r, p, level+1)
+		}
+	}
+}
+
+func randomPattern(r *rand.Rand) string {
+	segments := make([]string, 1+r.IntN(3))
+	for i := range segments {
+		segments[i] = stdlibSegments[r.IntN(len(stdlibSegments))]
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestStdlibCompat(t *testing.T) {
+	t.Parallel()
+	if isWindows() {
+		t.Skip("filepath.Glob has a different syntax on windows")
+	}
+
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+	for range 10 {
+		root := t.TempDir()
+		randomTree(t, r, root, 1)
+		fsys := os.DirFS(root)
+
+		patterns := []string{"*", "*/*", "*/*/*", ".", "a", "nope", "nope/*", "[", "a/[", `a\`, "[a-]"}
+		for range 200 {
+			patterns = append(patterns, randomPattern(r))
+		}
+		for _, pattern := range patterns {
+			expected, expectedErr := filepath.Glob(filepath.Join(root, pattern))
+			for i := range expected {
+				rel, err := filepath.Rel(root, expected[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected[i] = filepath.ToSlash(rel)
+			}
+			matches, err := Glob(pattern, WithFs(fsys), StdlibCompat)
+			if expectedErr != nil || err != nil {
+				if !errors.Is(err, expectedErr) {
+					t.Errorf("%s: expected error %v, got %v", pattern, expectedErr, err)
+				}
+				continue
+			}
+			if !slicesEqual(expected, matches) {
+				t.Errorf("%s: expected %q, got %q", pattern, expected, matches)
+			}
+		}
+	}
+}
+
+// slicesEqual is like slices.Equal, but also tells nil from empty slices.
+func slicesEqual(a, b []string) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStdlibCompatOptions(t *testing.T) {
+	t.Parallel()
+	if isWindows() {
+		t.Skip("filepath.Glob has a different syntax on windows")
+	}
+
+	root := t.TempDir()
+	for _, dir := range []string{"a/b", "a/c", "b"} {
+		is.New(t).NoErr(os.MkdirAll(filepath.Join(root, dir), 0o700))
+	}
+	is.New(t).NoErr(os.WriteFile(filepath.Join(root, "a/b/x"), nil, 0o600))
+
+	t.Run("absolute", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		pattern := filepath.ToSlash(root) + "/a/*"
+		expected, err := filepath.Glob(pattern)
+		is.NoErr(err)
+		matches, err := Glob(pattern, MaybeRootFS, StdlibCompat)
+		is.NoErr(err)
+		is.Equal(expected, matches)
+	})
+
+	t.Run("unreadable", func(t *testing.T) {
+		t.Parallel()
+		if os.Getuid() == 0 {
+			t.Skip("root can read everything")
+		}
+		is := is.New(t)
+		dir := filepath.Join(t.TempDir(), "locked")
+		is.NoErr(os.MkdirAll(filepath.Join(dir, "x"), 0o700))
+		is.NoErr(os.Chmod(dir, 0))
+		t.Cleanup(func() { _ = os.Chmod(dir, 0o700) })
+		fsys := os.DirFS(filepath.Dir(dir))
+		matches, err := Glob("*/*", WithFs(fsys), StdlibCompat)
+		is.NoErr(err)
+		is.Equal([]string(nil), matches)
+		_, err = Glob("*/*", WithFs(fsys))
+		is.True(err != nil) // should fail without StdlibCompat
+	})
+
+	t.Run("prunes", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		var stats Stats
+		matches, err := Glob("*", WithFs(os.DirFS(root)), StdlibCompat, WithStats(&stats))
+		is.NoErr(err)
+		is.Equal([]string{"a", "b"}, matches)
+		is.Equal(int64(1), stats.DirsRead) // should only read the root
+	})
+
+	t.Run("symlinks", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		dir := t.TempDir()
+		is.NoErr(os.Mkdir(filepath.Join(dir, "real"), 0o700))
+		is.NoErr(os.WriteFile(filepath.Join(dir, "real", "f"), nil, 0o600))
+		is.NoErr(os.Symlink("real", filepath.Join(dir, "link")))
+		is.NoErr(os.Symlink("nope", filepath.Join(dir, "broken")))
+		fsys := os.DirFS(dir)
+
+		for _, pattern := range []string{"*/f", "link/*", "*", "broken"} {
+			expected, err := filepath.Glob(filepath.Join(dir, pattern))
+			is.NoErr(err)
+			for i := range expected {
+				expected[i] = filepath.ToSlash(strings.TrimPrefix(expected[i], dir+string(filepath.Separator)))
+			}
+			matches, err := Glob(pattern, WithFs(fsys), StdlibCompat)
+			is.NoErr(err)
+			is.Equal(expected, matches)
+		}
+		matches, err := Glob("*/f", WithFs(fsys), StdlibCompat)
+		is.NoErr(err)
+		is.Equal([]string{"link/f", "real/f"}, matches)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		is.Equal(filepath.ErrBadPattern, ValidPattern("a/[", StdlibCompat))
+		is.NoErr(ValidPattern("{a,[b]}", StdlibCompat))
+	})
+}
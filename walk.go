@@ -0,0 +1,296 @@
+package fileglob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+// visitFunc is called for every entry found while walking, and returns the
+// given matches with the ones it found appended.
+//
+// If the entry is inside of a directory for which visit returned inherit,
+// inherited is true. The returned error has the same meaning as the one
+// returned by a fs.WalkDirFunc.
+type visitFunc func(path string, d fs.DirEntry, inherited bool, matches []string) (_ []string, inherit bool, _ error)
+
+// walk walks the file tree rooted at root, calling visit for each entry, and
+// returns the given matches with the collected ones appended, in the order
+// fs.WalkDir would visit them.
+func walk(options *globOptions, root string, d fs.DirEntry, visit visitFunc, matches []string) ([]string, error) {
+	limit := options.limit
+	if !options.full(limit) {
+		// matches are sorted afterwards, so all of them have to be found
+		limit = 0
+	}
+	w := &walker{
+		fs:             options.fs,
+		visit:          visit,
+		limit:          limit,
+		counters:       options.counters,
+		skipUnreadable: options.stdlibCompat,
+		followSymlinks: options.stdlibCompat,
+		sem:            make(chan struct{}, max(options.concurrency-1, 0)),
+	}
+	matches, err := w.walk(nil, root, d, false, matches)
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return matches, nil
+	}
+	return matches, err
+}
+
+// walker walks directories, concurrently if allowed to.
+//
+// Entries are walked in order, appending to the same matches, until one of
+// them is handed to another goroutine. From then on, each entry of that
+// directory collects its own matches in a slot, which are merged in order
+// once all of them are done, so the result is the same as the one of a
+// sequential walk.
+type walker struct {
+	fs    fs.FS
+	visit visitFunc
+	// limit is the number of matches after which the walk stops, or 0.
+	limit    int
+	counters *counters
+
+	// skipUnreadable makes directories that can not be read be skipped, like
+	// filepath.Glob does, instead of failing the walk.
+	skipUnreadable bool
+	// followSymlinks makes symbolic links to directories be walked as the
+	// directories they link to, like filepath.Glob does. Nothing else stops
+	// loops of links, so it is only set when the walk depth is limited.
+	followSymlinks bool
+
+	// sem holds a token for each goroutine walking a directory, besides the
+	// calling one.
+	sem chan struct{}
+
+	mu sync.Mutex
+	// err is the error or fs.SkipAll that stopped the walk the earliest in
+	// walk order, in the slot stopped, after which nothing has to be walked
+	// anymore.
+	err     error
+	stopped *slot
+}
+
+// slot collects the matches of an entry whose directory had entries handed to
+// other goroutines. A nil slot is the one of the calling goroutine.
+type slot struct {
+	group *slotGroup
+	index int
+	// found is the number of matches collected so far.
+	found atomic.Int64
+
+	matches []string
+	err     error
+}
+
+// slotGroup are the slots of the entries of a directory, from the first one
+// handed to another goroutine on.
+type slotGroup struct {
+	parent *slot
+	// before is the number of matches the parent slot collected before the
+	// group.
+	before int
+	slots  []slot
+}
+
+// preceding returns how many matches come before the ones of the slot in walk
+// order, as far as is known yet, which is never more than the actual number.
+func (s *slot) preceding() int {
+	if s == nil {
+		return 0
+	}
+	n := s.group.parent.preceding() + s.group.before
+	for i := range s.index {
+		n += int(s.group.slots[i].found.Load())
+	}
+	return n
+}
+
+// path returns the indexes of the slot and its parents, from the root on.
+func (s *slot) path() []int {
+	if s == nil {
+		return nil
+	}
+	return append(s.group.parent.path(), s.index)
+}
+
+// isBefore reports whether everything the slot collects comes before what
+// other collects, in walk order. Neither is before the other if one is inside
+// of the other.
+func (s *slot) isBefore(other *slot) bool {
+	a, b := s.path(), other.path()
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// reachedLimit records the number of matches of the slot, and reports whether
+// the walk can stop there, as the limit has been reached.
+func (w *walker) reachedLimit(s *slot, matches []string) bool {
+	if w.limit <= 0 {
+		return false
+	}
+	if s != nil {
+		s.found.Store(int64(len(matches)))
+	}
+	return s.preceding()+len(matches) >= w.limit
+}
+
+// walk visits the given entry and, if it is a directory, its contents,
+// appending what they match to matches.
+//
+// Like a fs.WalkDirFunc, it returns fs.SkipDir when the remaining entries of
+// the parent directory should be skipped, and fs.SkipAll when the remaining
+// entries of the whole tree should be skipped.
+func (w *walker) walk(s *slot, name string, d fs.DirEntry, inherited bool, matches []string) ([]string, error) {
+	if err := w.stoppedBefore(s); err != nil {
+		return matches, err
+	}
+
+	if w.followSymlinks && d.Type()&fs.ModeSymlink != 0 {
+		w.counters.statCalls.Add(1)
+		if info, err := fs.Stat(w.fs, name); err == nil && info.IsDir() {
+			d = fs.FileInfoToDirEntry(info)
+		}
+	}
+
+	w.counters.entriesVisited.Add(1)
+	n := len(matches)
+	matches, inherit, err := w.visit(name, d, inherited, matches)
+	if errors.Is(err, fs.SkipDir) {
+		w.counters.subtreesPruned.Add(1)
+	}
+	// the matches preceding the slot may have reached the limit meanwhile, so
+	// it is checked before reading directories as well
+	if err == nil && (len(matches) > n || d.IsDir()) && w.reachedLimit(s, matches) {
+		err = fs.SkipAll
+	}
+	if err != nil || !d.IsDir() {
+		if errors.Is(err, fs.SkipDir) && d.IsDir() {
+			return matches, nil
+		}
+		return matches, w.stop(s, err)
+	}
+
+	w.counters.dirsRead.Add(1)
+	entries, err := fs.ReadDir(w.fs, name)
+	if err != nil && w.skipUnreadable {
+		w.counters.errorsSkipped.Add(1)
+		return matches, nil
+	}
+	if err != nil {
+		return matches, w.stop(s, err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		group  *slotGroup
+		offset int // index of the entry slots start at
+	)
+	for i, entry := range entries {
+		// fs.FS paths are always slash separated, which is what the glob
+		// matchers expect as well
+		child := path.Join(name, entry.Name())
+		concurrent := entry.IsDir() && w.acquire()
+		if concurrent && group == nil {
+			group = &slotGroup{parent: s, before: len(matches), slots: make([]slot, len(entries)-i)}
+			for j := range group.slots {
+				group.slots[j] = slot{group: group, index: j}
+			}
+			offset = i
+		}
+
+		if group == nil {
+			matches, err = w.walk(s, child, entry, inherit, matches)
+			if errors.Is(err, fs.SkipDir) {
+				return matches, nil
+			}
+			if err != nil {
+				return matches, err
+			}
+			continue
+		}
+
+		result := &group.slots[i-offset]
+		if concurrent {
+			wg.Go(func() {
+				defer w.release()
+				result.matches, result.err = w.walk(result, child, entry, inherit, nil)
+			})
+			continue
+		}
+
+		result.matches, result.err = w.walk(result, child, entry, inherit, nil)
+		if result.err != nil {
+			// the following entries would be skipped by the merge anyway
+			break
+		}
+	}
+	wg.Wait()
+	if group == nil {
+		return matches, nil
+	}
+
+	// once the limit is reached, what comes after in walk order, errors
+	// included, would not have been walked
+	for i := range group.slots {
+		result := &group.slots[i]
+		matches = append(matches, result.matches...)
+		if w.reachedLimit(s, matches) {
+			return matches, w.stop(s, fs.SkipAll)
+		}
+		if errors.Is(result.err, fs.SkipDir) {
+			return matches, nil
+		}
+		if result.err != nil {
+			return matches, result.err
+		}
+	}
+	return matches, nil
+}
+
+func (w *walker) acquire() bool {
+	select {
+	case w.sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *walker) release() {
+	<-w.sem
+}
+
+// stop records an error, or a fs.SkipAll, which stops walking what comes
+// after the slot in walk order. fs.SkipDir is returned as is.
+func (w *walker) stop(s *slot, err error) error {
+	if err == nil || errors.Is(err, fs.SkipDir) {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil || s.isBefore(w.stopped) {
+		w.err = err
+		w.stopped = s
+	}
+	return err
+}
+
+// stoppedBefore returns the error that stopped the walk before the slot in
+// walk order, if any, as what it collects would not be walked sequentially.
+func (w *walker) stoppedBefore(s *slot) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil && w.stopped.isBefore(s) {
+		return w.err
+	}
+	return nil
+}
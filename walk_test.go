@@ -0,0 +1,207 @@
+package fileglob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWithConcurrency(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{}
+	for _, dir := range []string{"a", "a/b", "a/b/c", "d", "d/e", "f", "g/h/i"} {
+		for _, file := range []string{"1.txt", "2.go", "3.txt"} {
+			fsys[dir+"/"+file] = &fstest.MapFile{}
+		}
+	}
+
+	testCases := []struct {
+		pattern string
+		opts    []OptFunc
+	}{
+		{"**/*.txt", nil},
+		{"*/*", nil},
+		{"{a,d}", nil},
+		{"**/[bh]", nil},
+		{"**/[bh]", []OptFunc{MatchDirectoryAsFile}},
+		{"**/*.go", []OptFunc{WithLimit(4)}},
+		{"**/*.go", []OptFunc{WithDirFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			return path != "a/b", nil
+		})}},
+		{"**/*.txt", []OptFunc{WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			if path == "a/b/1.txt" {
+				return false, fs.SkipDir
+			}
+			return true, nil
+		})}},
+		{"**/*.txt", []OptFunc{WithFilter(func(path string, _ fs.DirEntry) (bool, error) {
+			if path == "d/e/1.txt" {
+				return false, fs.SkipAll
+			}
+			return true, nil
+		})}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			opts := append([]OptFunc{WithFs(fsys)}, testCase.opts...)
+			expected, err := Glob(testCase.pattern, opts...)
+			is.NoErr(err)
+			is.True(len(expected) > 0) // expected matches
+			for _, n := range []int{2, 4, 16} {
+				matches, err := Glob(testCase.pattern, append(opts, WithConcurrency(n))...)
+				is.NoErr(err)
+				is.Equal(expected, matches)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		is := is.New(t)
+		errRead := errors.New("read failed")
+		matches, err := Glob("**/*.txt", WithConcurrency(4), WithFs(failingFS{
+			FS: fsys,
+			errs: map[string]error{
+				"d/e": errRead,
+			},
+		}))
+		is.True(errors.Is(err, errRead))
+		is.Equal(nil, matches)
+	})
+
+	t.Run("limit before an error", func(t *testing.T) {
+		t.Parallel()
+		errRead := errors.New("boom")
+		// reading c fails right away, while a and b are slow to read
+		fsys := failingFS{
+			FS: slowFS{FS: fstest.MapFS{
+				"a/1": {},
+				"b/2": {},
+				"c/3": {},
+			}, latency: 10 * time.Millisecond},
+			errs: map[string]error{"c": errRead},
+		}
+
+		for _, n := range []int{1, 2, 4} {
+			t.Run(fmt.Sprint(n), func(t *testing.T) {
+				t.Parallel()
+				is := is.New(t)
+				matches, err := Glob("*/*", WithFs(fsys), WithLimit(2), WithConcurrency(n))
+				is.NoErr(err)
+				is.Equal([]string{"a/1", "b/2"}, matches)
+
+				exists, err := Exists("*/*", WithFs(fsys), WithConcurrency(n))
+				is.NoErr(err)
+				is.True(exists)
+
+				_, err = Glob("*/*", WithFs(fsys), WithLimit(3), WithConcurrency(n))
+				is.True(errors.Is(err, errRead))
+			})
+		}
+	})
+}
+
+func BenchmarkGlob(b *testing.B) {
+	fsys := fstest.MapFS{}
+	for i := range 10 {
+		for j := range 10 {
+			for k := range 5 {
+				fsys[fmt.Sprintf("%d/%d/%d.txt", i, j, k)] = &fstest.MapFile{}
+			}
+		}
+	}
+	slow := slowFS{FS: fsys, latency: 50 * time.Microsecond}
+
+	for _, n := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency %d", n), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := Glob("**/*.txt", WithFs(fsys), WithConcurrency(n)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("slow concurrency %d", n), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := Glob("**/*.txt", WithFs(slow), WithConcurrency(n)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// failingFS fails to read the given directories.
+type failingFS struct {
+	fs.FS
+	errs map[string]error
+}
+
+func (f failingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err, ok := f.errs[strings.TrimSuffix(name, "/")]; ok {
+		return nil, err
+	}
+	return fs.ReadDir(f.FS, name)
+}
+
+// slowFS adds latency to reading directories, like a networked file system.
+type slowFS struct {
+	fs.FS
+	latency time.Duration
+}
+
+func (f slowFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	time.Sleep(f.latency)
+	return fs.ReadDir(f.FS, name)
+}
+
+func BenchmarkGlobMatchingDirectories(b *testing.B) {
+	fsys := fstest.MapFS{}
+	for i := range 10 {
+		for j := range 10 {
+			for k := range 5 {
+				fsys[fmt.Sprintf("%d/%d/%d/%d.txt", i, j, k, k)] = &fstest.MapFile{}
+			}
+		}
+	}
+
+	for _, pattern := range []string{"*", "*/*", "**/[0-4]"} {
+		b.Run(pattern, func(b *testing.B) {
+			counting := &countingFS{FS: fsys}
+			for b.Loop() {
+				if _, err := Glob(pattern, WithFs(counting)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(counting.readDirs.Load())/float64(b.N), "readdirs/op")
+			b.ReportMetric(float64(counting.stats.Load())/float64(b.N), "stats/op")
+		})
+	}
+}
+
+// countingFS counts the calls to ReadDir and Stat.
+type countingFS struct {
+	fs.FS
+	readDirs atomic.Int64
+	stats    atomic.Int64
+}
+
+func (f *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.readDirs.Add(1)
+	return fs.ReadDir(f.FS, name)
+}
+
+func (f *countingFS) Stat(name string) (fs.FileInfo, error) {
+	f.stats.Add(1)
+	return fs.Stat(f.FS, name)
+}